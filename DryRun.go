@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Dry run. DB_DryRun is a DB_Executor which records each statement and its args, and never touches a database.
+	Pass it to any DB_* function or DBJob Run in place of db, then read the statements back. (ex. code review tools, golden file tests)
+	Dry run. DB_DryRun 은 각 구문과 인자를 기록하기만 하고 DB 에는 접근하지 않는 DB_Executor.
+	DB_* 함수나 DBJob Run 에 db 대신 넘긴 뒤, 기록된 구문을 읽음. (ex. 코드 리뷰 도구, golden file 테스트)
+
+	< 주의점 >
+	1. every statement succeeds, affects 0 rows and reads no rows. (ADD_DEFER sees those results, and ADD_SELECT dest stays empty)
+	2. DB_Expect of DBJob is taken as met, so the plan is the one of a successful Run.
+	3. Redis jobs of DBJob are recorded as "REDIS <command>" and not sent.
+	4. args are recorded as given, not converted by driver.Valuer.
+	5. Close it when done, it holds a *sql.DB.
+
+	ex)
+		dry := NewDryRun(DB_MySQL)
+		defer dry.Close()
+		DB_UPDATE(dry, tbl_target, tbl_where)
+		statements := dry.Statements()	<- [{UPDATE `tblaccount` SET `PlayerKey` = ? WHERE `GameDBID` = ?; [x 1]}]
+
+		statements, err := dbjob.Preview(DB_MySQL)
+		fmt.Print(DB_FormatPlan(statements))
+*/
+type DB_DryRun struct {
+	dialect    DB_Dialect
+	db         *sql.DB
+	statements []DB_Statement
+	mutex      sync.Mutex
+}
+
+type DB_Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+func NewDryRun(d DB_Dialect) *DB_DryRun {
+	dry := &DB_DryRun{dialect: d}
+	dry.db = sql.OpenDB(db_DryRunConnector{dry: dry})
+	return dry
+}
+
+func (dry *DB_DryRun) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return dry.db.ExecContext(ctx, query, args...)
+}
+
+func (dry *DB_DryRun) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return dry.db.QueryContext(ctx, query, args...)
+}
+
+func (dry *DB_DryRun) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return dry.db.QueryRowContext(ctx, query, args...)
+}
+
+/*
+	With BeginTx, DBJob Run opens its own transaction on the recorder, so BEGIN / COMMIT are in the plan.
+	BeginTx 가 있으므로 DBJob Run 은 recorder 에서 자신의 트랜잭션을 열고, BEGIN / COMMIT 도 계획에 포함됨.
+*/
+func (dry *DB_DryRun) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return dry.db.BeginTx(ctx, opts)
+}
+
+func (dry *DB_DryRun) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	command := make([]string, len(args))
+	for i, arg := range args {
+		str, err := redis_ArgString(arg)
+		if err != nil {
+			return nil, err
+		}
+		command[i] = str
+	}
+
+	dry.record("REDIS "+strings.Join(command, " "), nil)
+	return nil, nil
+}
+
+func (dry *DB_DryRun) Close() error {
+	return dry.db.Close()
+}
+
+/*
+	Statements recorded so far, in the order they would run.
+	지금까지 기록된 구문, 실행될 순서대로.
+*/
+func (dry *DB_DryRun) Statements() []DB_Statement {
+	dry.mutex.Lock()
+	defer dry.mutex.Unlock()
+
+	return append([]DB_Statement(nil), dry.statements...)
+}
+
+func (dry *DB_DryRun) Reset() {
+	dry.mutex.Lock()
+	defer dry.mutex.Unlock()
+
+	dry.statements = nil
+}
+
+func (dry *DB_DryRun) record(query string, args []driver.NamedValue) {
+	var values []interface{}
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+
+	dry.mutex.Lock()
+	defer dry.mutex.Unlock()
+
+	dry.statements = append(dry.statements, DB_Statement{SQL: query, Args: values})
+}
+
+/*
+	Statements a Run of the jobs would execute on the dialect d, without touching a database.
+	ADD_DEFER functions are called with empty results, so they must not assume rows. (ex. items[0] of ADD_SELECT dest panics)
+	job 들을 Run 하면 방언 d 로 실행될 구문들, DB 에는 접근하지 않음.
+	ADD_DEFER 함수는 빈 결과로 호출되므로, row 가 있다고 가정하면 안 됨. (ex. ADD_SELECT dest 의 items[0] 은 panic)
+*/
+func (dbjob *DBJob) Preview(d DB_Dialect) ([]DB_Statement, error) {
+	dry := NewDryRun(d)
+	defer dry.Close()
+
+	if _, err := dbjob.RunContext(context.Background(), dry); err != nil {
+		return nil, err
+	}
+
+	return dry.Statements(), nil
+}
+
+/*
+	Numbered plan of the statements, with the args of each under it. The output is stable, so it can be a golden file.
+	구문들의 번호 붙은 계획으로, 각 구문 아래에 인자를 표시. 출력이 일정하므로 golden file 로 쓸 수 있음.
+
+	ex)
+		1. BEGIN;
+		2. UPDATE `tblaccount` SET `PlayerKey` = ? WHERE `GameDBID` = ?;
+		   -- args: "x", 1
+		3. COMMIT;
+*/
+func DB_FormatPlan(statements []DB_Statement) string {
+	var buf strings.Builder
+	for i, statement := range statements {
+		number := strconv.Itoa(i+1) + ". "
+		buf.WriteString(number + statement.SQL + "\n")
+		if 0 == len(statement.Args) {
+			continue
+		}
+
+		args := make([]string, len(statement.Args))
+		for k, arg := range statement.Args {
+			args[k] = db_FormatArg(arg)
+		}
+		buf.WriteString(strings.Repeat(" ", len(number)) + "-- args: " + strings.Join(args, ", ") + "\n")
+	}
+
+	return buf.String()
+}
+
+func db_FormatArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return "x'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return strconv.Quote(v.Format(time.RFC3339Nano))
+	}
+
+	return fmt.Sprint(arg)
+}
+
+/*
+	database/sql driver of DB_DryRun, each connection records into the same DB_DryRun.
+	DB_DryRun 의 database/sql 드라이버, 모든 연결이 같은 DB_DryRun 에 기록.
+*/
+type db_DryRunConnector struct {
+	dry *DB_DryRun
+}
+
+type db_DryRunConn struct {
+	dry *DB_DryRun
+}
+
+type db_DryRunTx struct {
+	dry *DB_DryRun
+}
+
+type db_DryRunResult struct{}
+
+type db_DryRunRows struct{}
+
+func (connector db_DryRunConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return db_DryRunConn{dry: connector.dry}, nil
+}
+
+func (connector db_DryRunConnector) Driver() driver.Driver { return db_DryRunDriver{} }
+
+type db_DryRunDriver struct{}
+
+func (db_DryRunDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("[ DryRun Error ] Open is not supported, use NewDryRun")
+}
+
+func (conn db_DryRunConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New(fmt.Sprint("[ DryRun Error ] Prepare is not supported - ", query))
+}
+
+func (conn db_DryRunConn) Close() error { return nil }
+
+func (conn db_DryRunConn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn db_DryRunConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	conn.dry.record("BEGIN;", nil)
+	return db_DryRunTx{dry: conn.dry}, nil
+}
+
+func (conn db_DryRunConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.dry.record(query, args)
+	return db_DryRunResult{}, nil
+}
+
+func (conn db_DryRunConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.dry.record(query, args)
+	return db_DryRunRows{}, nil
+}
+
+/*
+	Takes every arg as is, so the recorded args are the ones given by the caller.
+	모든 인자를 그대로 받아, 호출자가 넘긴 인자가 기록되도록 함.
+*/
+func (conn db_DryRunConn) CheckNamedValue(arg *driver.NamedValue) error { return nil }
+
+func (tx db_DryRunTx) Commit() error {
+	tx.dry.record("COMMIT;", nil)
+	return nil
+}
+
+func (tx db_DryRunTx) Rollback() error {
+	tx.dry.record("ROLLBACK;", nil)
+	return nil
+}
+
+func (db_DryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (db_DryRunResult) RowsAffected() (int64, error) { return 0, nil }
+
+func (db_DryRunRows) Columns() []string              { return nil }
+func (db_DryRunRows) Close() error                   { return nil }
+func (db_DryRunRows) Next(dest []driver.Value) error { return io.EOF }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+)
+
+var test_UpdateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+/*
+Compares got with testdata/name, or rewrites it with -update.
+got 을 testdata/name 과 비교하거나, -update 로 다시 씀.
+*/
+func test_Golden(t *testing.T, name string, got string) {
+	t.Helper()
+
+	path := "testdata/" + name
+	if true == *test_UpdateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(want) != got {
+		t.Fatalf("%v differs\n--- got\n%v--- want\n%v", path, got, want)
+	}
+}
+
+func TestPreviewPlan(t *testing.T) {
+	var dbjob DBJob
+	dbjob.SetCoalesce(DB_CoalescePolicy{})
+
+	var first, second test_Profile
+	DB_InitTable(&first, &second)
+	first.Name = "alice"
+	first.Guild = "red"
+	second.Name = "bob"
+	second.Guild = "blue"
+	ADD_INSERT(&dbjob, first)
+	ADD_INSERT(&dbjob, second)
+
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Level = 10
+	tbl_where.Name = "alice"
+	ADD_TRY_SAVEPOINT(&dbjob, "bonus")
+	ADD_INCR(&dbjob, tbl_target, tbl_where, 10, DB_Lt("Level", 100))
+	ADD_RELEASE_SAVEPOINT(&dbjob, "bonus")
+
+	/*
+		The deferred jobs see the results of a dry run. (no rows, 0 affected)
+	*/
+	var items []test_Profile
+	ADD_SELECT(&dbjob, &items, tbl_target, tbl_where, DB_ForUpdate())
+	ADD_DEFER(&dbjob, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error {
+		if 0 == len(items) {
+			var tbl_delete test_Profile
+			DB_InitTable(&tbl_delete)
+			tbl_delete.Name = "bob"
+			return ADD_DELETE(deferred, tbl_delete)
+		}
+		return nil
+	})
+
+	statements, err := dbjob.Preview(DB_MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_Golden(t, "preview_plan.golden", DB_FormatPlan(statements))
+}
+
+func TestPreviewRedis(t *testing.T) {
+	redis, fake := test_OpenRedis(t, func(args []string) string { return ":1\r\n" })
+
+	/*
+		The second command fails in the dry run, the compensation of the first is recorded and not sent.
+		ADD_REDIS rejects an unsupported argument, so it is put in after ADD_REDIS.
+	*/
+	var dbjob DBJob
+	ADD_REDIS(&dbjob, redis, []interface{}{"ZADD", "k", 1, "m"}, []interface{}{"ZREM", "k", "m"})
+	ADD_REDIS(&dbjob, redis, []interface{}{"ZADD", "k", int32(2), "n"}, nil)
+	dbjob.queryList[1].redis.command[2] = struct{}{}
+	statements, err := dbjob.Preview(DB_MySQL)
+	if err == nil {
+		t.Fatal("unsupported Redis argument did not fail Preview")
+	}
+	if 0 != len(statements) {
+		t.Fatalf("got %v", statements)
+	}
+	if 0 != len(fake.Commands()) {
+		t.Fatalf("sent to Redis - %q", fake.Commands())
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+/*
+	Logger of ezDB. Info / Error lines go to stderr through the standard log package.
+	ezDB 의 로거. Info / Error 는 표준 log 패키지를 통해 stderr 로 출력.
+*/
+type Logger struct {
+	info  *log.Logger
+	error *log.Logger
+}
+
+func InitLogger() *Logger {
+	return &Logger{
+		info:  log.New(os.Stderr, "[ INFO ] ", log.LstdFlags),
+		error: log.New(os.Stderr, "[ ERROR ] ", log.LstdFlags),
+	}
+}
+
+var logger = InitLogger()
+
+func (l *Logger) Info(v ...interface{}) {
+	l.info.Output(2, fmt.Sprintln(v...))
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	l.error.Output(2, fmt.Sprintln(v...))
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.error.Output(2, fmt.Sprintf(format, v...))
+}
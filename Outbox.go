@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+	Transactional outbox. ADD_OUTBOX writes a message row in the transaction of the DBJob, so the message exists only if the jobs are committed.
+	DB_OutboxDispatcher polls the table and gives each message to the handler of its topic, at least once. (a handler may see the same message again)
+	트랜잭셔널 아웃박스. ADD_OUTBOX 는 DBJob 의 트랜잭션에서 메시지 row 를 쓰므로, job 들이 commit 된 경우에만 메시지가 존재함.
+	DB_OutboxDispatcher 는 테이블을 polling 하며 각 메시지를 topic 의 handler 에 최소 한 번 전달. (handler 는 같은 메시지를 다시 받을 수 있음)
+
+	< 주의점 >
+	1. the table must exist. (MySQL)
+		CREATE TABLE db_outbox (
+			OutboxID   BIGINT AUTO_INCREMENT PRIMARY KEY,
+			Topic      VARCHAR(128) NOT NULL,
+			Payload    TEXT NOT NULL,
+			Status     INT NOT NULL,
+			TryCount   INT NOT NULL,
+			LastError  TEXT NOT NULL,
+			CreateTime DATETIME NOT NULL,
+			NextTime   DATETIME NOT NULL,
+			INDEX (Status, NextTime)
+		);
+	2. handlers must be idempotent.
+
+	ex)
+		ADD_UPDATE(&dbjob, tbl_profile, tbl_where)
+		ADD_OUTBOX(&dbjob, "rank.follow", RankFollow{PlatformID: id, Follow: follow})
+		dbjob.Run(db)
+
+		dispatcher := NewOutboxDispatcher(db)
+		dispatcher.Handle("rank.follow", func(ctx context.Context, msg DB_Outbox) error { ... })
+		dispatcher.Start(ctx)
+*/
+type DB_Outbox struct {
+	OutboxID   int64 `PK:"true"`
+	Topic      string
+	Payload    string
+	Status     int
+	TryCount   int
+	LastError  string
+	CreateTime time.Time
+	NextTime   time.Time
+}
+
+func (DB_Outbox) TableName() string { return "db_outbox" }
+
+const (
+	DB_OUTBOX_PENDING = 0
+	DB_OUTBOX_DONE    = 1
+	DB_OUTBOX_DEAD    = 2 // MaxRetry is over. MaxRetry 초과.
+)
+
+/*
+	payload is saved as JSON. ([]byte / string as is)
+	payload 는 JSON 으로 저장. ([]byte / string 은 그대로)
+*/
+func ADD_OUTBOX(dbjob *DBJob, topic string, payload interface{}) error {
+	var data string
+	switch v := payload.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			logger.Error("[ DBJob Error ] AddJob - Outbox payload marshal failed - ", err)
+			dbjob.readyNextProcess(err)
+			return err
+		}
+		data = string(bytes)
+	}
+
+	now := time.Now()
+	var msg DB_Outbox
+	DB_InitTable(&msg)
+	msg.Topic = topic
+	msg.Payload = data
+	msg.Status = DB_OUTBOX_PENDING
+	msg.TryCount = 0
+	msg.LastError = ""
+	msg.CreateTime = now
+	msg.NextTime = now
+
+	return ADD_INSERT(dbjob, msg)
+}
+
+type DB_OutboxHandler func(ctx context.Context, msg DB_Outbox) error
+
+/*
+	Retry.MaxRetry is the number of failed deliveries before a message is DB_OUTBOX_DEAD, 0 means forever.
+	The wait after a failure follows Retry like DBJob, Retry.OnRetry is called on each failure.
+	A message being delivered is leased for Lease, so another dispatcher does not take it until the lease is over.
+	Retry.MaxRetry 는 메시지가 DB_OUTBOX_DEAD 가 되기 전까지 실패한 전달 횟수이며, 0 이면 무한.
+	실패 후 대기는 DBJob 과 같이 Retry 를 따르며, Retry.OnRetry 는 실패할 때마다 호출됨.
+	전달 중인 메시지는 Lease 동안 점유되어, lease 가 끝날 때까지 다른 dispatcher 가 가져가지 않음.
+*/
+type DB_OutboxDispatcher struct {
+	Interval  time.Duration // default 1s. 기본 1s.
+	BatchSize int64         // default 100. 기본 100.
+	Lease     time.Duration // default 1m. 기본 1m.
+	Retry     DB_RetryPolicy
+
+	db       DB_Executor
+	handlers map[string]DB_OutboxHandler
+	mutex    sync.RWMutex
+}
+
+func NewOutboxDispatcher(db DB_Executor) *DB_OutboxDispatcher {
+	return &DB_OutboxDispatcher{
+		Interval:  time.Second,
+		BatchSize: 100,
+		Lease:     time.Minute,
+		Retry:     DB_RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Minute},
+		db:        db,
+		handlers:  make(map[string]DB_OutboxHandler),
+	}
+}
+
+func (dispatcher *DB_OutboxDispatcher) Handle(topic string, handler DB_OutboxHandler) {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+
+	dispatcher.handlers[topic] = handler
+}
+
+/*
+	Starts the polling goroutine, which ends when ctx is done.
+	polling goroutine 을 시작하며, ctx 가 끝나면 종료됨.
+*/
+func (dispatcher *DB_OutboxDispatcher) Start(ctx context.Context) {
+	interval := dispatcher.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := dispatcher.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("[ Outbox Error ] Dispatch failed - ", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+/*
+	Delivers one batch of due messages, and returns how many were given to handlers.
+	기한이 된 메시지를 한 묶음 전달하고, handler 에 넘긴 수를 반환.
+*/
+func (dispatcher *DB_OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	batch_size := dispatcher.BatchSize
+	if batch_size <= 0 {
+		batch_size = 100
+	}
+
+	var tbl_select, tbl_where DB_Outbox
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.OutboxID = 0
+	tbl_select.Topic = ""
+	tbl_select.Payload = ""
+	tbl_select.TryCount = 0
+	tbl_select.NextTime = time.Now()
+	tbl_where.Status = DB_OUTBOX_PENDING
+
+	now := time.Now()
+	msgs, err := DB_SELECT_Context(ctx, dispatcher.db, tbl_select, tbl_where, DB_Le("NextTime", now), DB_OrderBy("OutboxID", DB_ASC), DB_Limit(batch_size))
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range msgs {
+		if err = ctx.Err(); err != nil {
+			return delivered, err
+		}
+
+		claimed, err := dispatcher.claim(ctx, msg)
+		if err != nil {
+			return delivered, err
+		}
+		if false == claimed {
+			continue
+		}
+
+		if err = dispatcher.deliver(ctx, msg); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+/*
+	Moves NextTime of the message to the end of the lease. Only one dispatcher can move it from the value it read.
+	메시지의 NextTime 을 lease 끝으로 옮김. 읽은 값에서 옮길 수 있는 dispatcher 는 하나뿐.
+*/
+func (dispatcher *DB_OutboxDispatcher) claim(ctx context.Context, msg DB_Outbox) (bool, error) {
+	lease := dispatcher.Lease
+	if lease <= 0 {
+		lease = time.Minute
+	}
+
+	var tbl_target, tbl_where DB_Outbox
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.NextTime = time.Now().Add(lease)
+	tbl_where.OutboxID = msg.OutboxID
+	tbl_where.Status = DB_OUTBOX_PENDING
+	tbl_where.NextTime = msg.NextTime
+
+	affected, err := DB_UPDATE_Context(ctx, dispatcher.db, tbl_target, tbl_where)
+	return 0 < affected, err
+}
+
+func (dispatcher *DB_OutboxDispatcher) deliver(ctx context.Context, msg DB_Outbox) error {
+	dispatcher.mutex.RLock()
+	handler, ok := dispatcher.handlers[msg.Topic]
+	dispatcher.mutex.RUnlock()
+
+	var handle_err error
+	if false == ok {
+		handle_err = errors.New(fmt.Sprint("[ Outbox Error ] No handler for topic - ", msg.Topic))
+	} else {
+		handle_err = dispatcher.handle(ctx, handler, msg)
+	}
+
+	var tbl_target, tbl_where DB_Outbox
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_where.OutboxID = msg.OutboxID
+
+	if handle_err == nil {
+		tbl_target.Status = DB_OUTBOX_DONE
+		tbl_target.TryCount = msg.TryCount + 1
+		_, err := DB_UPDATE_Context(ctx, dispatcher.db, tbl_target, tbl_where)
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	try_count := msg.TryCount + 1
+	delay := dispatcher.Retry.delay(try_count)
+	logger.Errorf("[ Outbox Error ] Message %v (%v) failed %v times - %v", msg.OutboxID, msg.Topic, try_count, handle_err)
+	if dispatcher.Retry.OnRetry != nil {
+		dispatcher.Retry.OnRetry(try_count, delay, handle_err)
+	}
+
+	tbl_target.TryCount = try_count
+	tbl_target.LastError = handle_err.Error()
+	tbl_target.NextTime = time.Now().Add(delay)
+	if 0 < dispatcher.Retry.MaxRetry && try_count >= dispatcher.Retry.MaxRetry {
+		tbl_target.Status = DB_OUTBOX_DEAD
+	}
+
+	_, err := DB_UPDATE_Context(ctx, dispatcher.db, tbl_target, tbl_where)
+	return err
+}
+
+/*
+	A panic in a handler is a failed delivery, not the end of the dispatcher.
+	handler 의 panic 은 전달 실패로 처리하며, dispatcher 를 종료시키지 않음.
+*/
+func (dispatcher *DB_OutboxDispatcher) handle(ctx context.Context, handler DB_OutboxHandler, msg DB_Outbox) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprint("[ Outbox Error ] Handler panic - ", r))
+		}
+	}()
+
+	return handler(ctx, msg)
+}
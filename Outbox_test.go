@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const test_OutboxDDL = `CREATE TABLE db_outbox (
+	OutboxID   INTEGER PRIMARY KEY AUTOINCREMENT,
+	Topic      TEXT NOT NULL,
+	Payload    TEXT NOT NULL,
+	Status     INT NOT NULL,
+	TryCount   INT NOT NULL,
+	LastError  TEXT NOT NULL,
+	CreateTime DATETIME NOT NULL,
+	NextTime   DATETIME NOT NULL
+)`
+
+func test_Outbox(t *testing.T, dispatcher *DB_OutboxDispatcher) DB_Outbox {
+	t.Helper()
+
+	var tbl_select, tbl_where DB_Outbox
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.OutboxID = 0
+	tbl_select.Payload = ""
+	tbl_select.Status = 0
+	tbl_select.TryCount = 0
+	tbl_select.LastError = ""
+	tbl_select.NextTime = time.Now()
+	tbl_where.Topic = "rank.follow"
+	rows, err := DB_SELECT(dispatcher.db, tbl_select, tbl_where)
+	if err != nil || 1 != len(rows) {
+		t.Fatalf("outbox rows %+v - %v", rows, err)
+	}
+	return rows[0]
+}
+
+func TestOutboxCommitted(t *testing.T) {
+	db := test_OpenSQLite(t, test_OutboxDDL, test_ProfileDDL)
+	ctx := context.Background()
+
+	var tbl_insert test_Profile
+	DB_InitTable(&tbl_insert)
+	tbl_insert.ProfileID = 1
+	tbl_insert.Name = "alice"
+
+	/*
+		The message of a rolled back DBJob does not exist.
+	*/
+	var failed DBJob
+	ADD_OUTBOX(&failed, "rank.follow", map[string]int{"follow": 1})
+	ADD_INSERT(&failed, tbl_insert, tbl_insert)
+	if _, err := failed.Run(db); err == nil {
+		t.Fatal("duplicate key did not fail")
+	}
+
+	var dbjob DBJob
+	ADD_INSERT(&dbjob, tbl_insert)
+	ADD_OUTBOX(&dbjob, "rank.follow", map[string]int{"follow": 1})
+	if _, err := dbjob.Run(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var payloads []string
+	dispatcher := NewOutboxDispatcher(db)
+	dispatcher.Handle("rank.follow", func(ctx context.Context, msg DB_Outbox) error {
+		payloads = append(payloads, msg.Payload)
+		return nil
+	})
+
+	delivered, err := dispatcher.DispatchOnce(ctx)
+	if err != nil || 1 != delivered || 1 != len(payloads) || `{"follow":1}` != payloads[0] {
+		t.Fatalf("delivered %v %q - %v", delivered, payloads, err)
+	}
+
+	msg := test_Outbox(t, dispatcher)
+	if DB_OUTBOX_DONE != msg.Status || 1 != msg.TryCount {
+		t.Fatalf("got %+v", msg)
+	}
+
+	if delivered, err = dispatcher.DispatchOnce(ctx); err != nil || 0 != delivered {
+		t.Fatalf("done message was delivered again - %v, %v", delivered, err)
+	}
+}
+
+func TestOutboxLease(t *testing.T) {
+	db := test_OpenSQLite(t, test_OutboxDDL)
+	ctx := context.Background()
+
+	var dbjob DBJob
+	ADD_OUTBOX(&dbjob, "rank.follow", "payload")
+	if _, err := dbjob.Run(db); err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewOutboxDispatcher(db)
+	second := NewOutboxDispatcher(db)
+	msg := test_Outbox(t, first)
+
+	/*
+		Only one dispatcher claims the message it read, the other one read a NextTime which is not there anymore.
+	*/
+	claimed, err := first.claim(ctx, msg)
+	if err != nil || false == claimed {
+		t.Fatalf("first claim - %v, %v", claimed, err)
+	}
+	if claimed, err = second.claim(ctx, msg); err != nil || true == claimed {
+		t.Fatalf("second claim - %v, %v", claimed, err)
+	}
+
+	/*
+		A leased message is not due, so no dispatcher takes it until the lease is over.
+	*/
+	second.Handle("rank.follow", func(ctx context.Context, msg DB_Outbox) error { return nil })
+	if delivered, err := second.DispatchOnce(ctx); err != nil || 0 != delivered {
+		t.Fatalf("leased message was delivered - %v, %v", delivered, err)
+	}
+	if leased := test_Outbox(t, first); false == leased.NextTime.After(time.Now().Add(30*time.Second)) {
+		t.Fatalf("lease - %v", leased.NextTime)
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	db := test_OpenSQLite(t, test_OutboxDDL)
+	ctx := context.Background()
+
+	var dbjob DBJob
+	ADD_OUTBOX(&dbjob, "rank.follow", "payload")
+	if _, err := dbjob.Run(db); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatcher := NewOutboxDispatcher(db)
+	dispatcher.Retry = DB_RetryPolicy{MaxRetry: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	dispatcher.Handle("rank.follow", func(ctx context.Context, msg DB_Outbox) error {
+		panic("handler failed")
+	})
+
+	/*
+		A failure keeps the message pending until the next try, and MaxRetry failures make it dead.
+	*/
+	if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	msg := test_Outbox(t, dispatcher)
+	if DB_OUTBOX_PENDING != msg.Status || 1 != msg.TryCount || "" == msg.LastError || false == msg.NextTime.After(time.Now()) {
+		t.Fatalf("after a failure - %+v", msg)
+	}
+
+	dispatcher.Handle("rank.follow", func(ctx context.Context, msg DB_Outbox) error {
+		return errors.New("handler failed")
+	})
+	if err := dispatcher.deliver(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg = test_Outbox(t, dispatcher); DB_OUTBOX_DEAD != msg.Status || 2 != msg.TryCount {
+		t.Fatalf("after MaxRetry failures - %+v", msg)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Secret key to sign bookmarks. A bookmark modified by the client fails the signature check.
+	Set it once on server start. (ex. Pager_BookmarkKey = []byte(config.PagerSecret)) GetResult fails while it is empty.
+	bookmark 를 서명하는 비밀키. 클라이언트가 수정한 bookmark 는 서명 검사에서 실패.
+	서버 시작 시 한 번 설정. (ex. Pager_BookmarkKey = []byte(config.PagerSecret)) 비어 있으면 GetResult 는 실패.
+*/
+var Pager_BookmarkKey []byte
+
+type PagerResult[DB_Table interface{}] struct {
+	Data         []DB_Table
+	Bookmark     string // next page. 다음 페이지.
+	PrevBookmark string // previous page. 이전 페이지.
+	IsEnd        bool   // no next page. 다음 페이지 없음.
+	IsFirst      bool   // no previous page. 이전 페이지 없음.
+}
+
+func (res PagerResult[DB_Table]) GetData() []DB_Table { return res.Data }
+func (res PagerResult[DB_Table]) GetBookmark() string { return res.Bookmark }
+
+/*
+	Keyset (cursor) pagination on top of DB_SELECT, the Go side of Pager.ts.
+	Instead of OFFSET, the next page starts after the ordering column values of the last row. => WHERE (a, b) < (?, ?)
+	Both Bookmark and PrevBookmark can be given back as the bookmark, the direction is kept inside the bookmark.
+	DB_SELECT 기반의 keyset (cursor) 페이지네이션, Pager.ts 의 Go 버전.
+	OFFSET 대신, 마지막 row 의 정렬 컬럼 값 다음부터 다음 페이지를 읽음. => WHERE (a, b) < (?, ?)
+	Bookmark 와 PrevBookmark 모두 bookmark 로 다시 넘길 수 있으며, 방향은 bookmark 안에 보관됨.
+
+	< 주의점 >
+	1. ordering columns must be selected in tbl_target, and must be NOT NULL.
+	2. ordering columns must be unique together. (ex. CreateTime, PlayerKey)
+
+	ex)
+		var tbl_select, tbl_where tblaccount
+		DB_InitTable(&tbl_select, &tbl_where)
+		tbl_select.PlayerKey = ""
+		tbl_select.UserUUID = 0
+		tbl_where.GameDBID = 3
+
+		pager := NewPager(db, tbl_select, tbl_where, []string{"UserUUID"}, afterCursor, 50)
+		pager.SetOrder(DB_DESC)
+		res, err := pager.GetResult()	<- SELECT ... WHERE `GameDBID` = ? AND (`UserUUID`) < (?) ORDER BY `UserUUID` DESC LIMIT 51;
+*/
+type Pager[DB_Table interface{}] struct {
+	db            DB_Executor
+	tbl_target    DB_Table
+	tbl_where     DB_Table
+	order_columns []string
+	order         DB_OrderDir
+	bookmark      string
+	page_size     int64
+	conditions    []interface{}
+}
+
+type pager_Bookmark struct {
+	Table    string        `json:"t"`
+	Columns  []string      `json:"c"`
+	Order    DB_OrderDir   `json:"o"`
+	Backward bool          `json:"b"`
+	Values   []string      `json:"v"`
+}
+
+func NewPager[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, order_columns []string, bookmark string, page_size int64) *Pager[DB_Table] {
+	return &Pager[DB_Table]{
+		db:            db,
+		tbl_target:    tbl_target,
+		tbl_where:     tbl_where,
+		order_columns: order_columns,
+		order:         DB_ASC,
+		bookmark:      bookmark,
+		page_size:     page_size,
+	}
+}
+
+func (pager *Pager[DB_Table]) SetOrder(order DB_OrderDir) {
+	pager.order = order
+}
+
+/*
+	Extra WHERE conditions. (DB_Cond or raw string) ORDER BY / LIMIT are made by the pager.
+	추가 WHERE 조건. (DB_Cond 또는 raw 문자열) ORDER BY / LIMIT 은 pager 가 생성.
+*/
+func (pager *Pager[DB_Table]) SetConditions(conditions ...interface{}) {
+	pager.conditions = conditions
+}
+
+func (pager *Pager[DB_Table]) GetResult() (PagerResult[DB_Table], error) {
+	return pager.GetResultContext(context.Background())
+}
+
+func (pager *Pager[DB_Table]) GetResultContext(ctx context.Context) (PagerResult[DB_Table], error) {
+	var res PagerResult[DB_Table]
+
+	if 0 == len(Pager_BookmarkKey) {
+		logger.Error("[ Pager Error ] Pager_BookmarkKey is not set")
+		return res, errors.New("[ Pager Error ] Pager_BookmarkKey is not set")
+	}
+
+	if 1 > pager.page_size {
+		return res, errors.New(fmt.Sprint("[ Pager Error ] Invalid page size - ", pager.page_size))
+	}
+
+	if 1 > len(pager.order_columns) {
+		return res, errors.New("[ Pager Error ] There is no ordering column")
+	}
+
+	if pager.order != DB_ASC && pager.order != DB_DESC {
+		return res, errors.New(fmt.Sprint("[ Pager Error ] Unknown order - ", pager.order))
+	}
+
+	/*
+		Ordering columns must be selected, their values make the next bookmark.
+		정렬 컬럼은 반드시 SELECT 되어야 하며, 그 값으로 다음 bookmark 를 만듦.
+	*/
+	tbl_type := reflect.TypeOf(pager.tbl_target)
+	var order_cols []db_ColumnInfo
+	for _, name := range pager.order_columns {
+		col, ok := db_FindColumn(tbl_type, name)
+		if false == ok {
+			return res, errors.New(fmt.Sprint("[ Pager Error ] Unknown ordering column - ", name))
+		}
+		if false == db_IsUse(reflect.ValueOf(pager.tbl_target).FieldByIndex(col.Index)) {
+			return res, errors.New(fmt.Sprint("[ Pager Error ] Ordering column is not selected - ", name))
+		}
+		order_cols = append(order_cols, col)
+	}
+
+	mark, err := pager_DecodeBookmark(pager.bookmark)
+	if err != nil {
+		logger.Error(err)
+		return res, err
+	}
+
+	if mark != nil {
+		if mark.Table != db_TableName(tbl_type) || strings.Join(mark.Columns, ",") != strings.Join(pager.order_columns, ",") || mark.Order != pager.order {
+			logger.Error("[ Pager Error ] Bookmark does not belong to this pager")
+			return res, errors.New("[ Pager Error ] Bookmark does not belong to this pager")
+		}
+	}
+
+	/*
+		Backward paging reads the reversed order from the bookmark, and reverses the rows back at the end.
+		이전 페이지는 bookmark 로부터 반대 순서로 읽고, 마지막에 row 순서를 다시 뒤집음.
+	*/
+	backward := mark != nil && mark.Backward
+	query_order := pager.order
+	if true == backward {
+		query_order = pager_Reverse(pager.order)
+	}
+
+	conditions := append([]interface{}{}, pager.conditions...)
+	if mark != nil {
+		op := ">"
+		if query_order == DB_DESC {
+			op = "<"
+		}
+		values, err := pager_DecodeValues(mark.Values)
+		if err != nil {
+			logger.Error(err)
+			return res, err
+		}
+		conditions = append(conditions, pager_KeysetCond{columns: pager.order_columns, op: op, values: values})
+	}
+	for _, name := range pager.order_columns {
+		conditions = append(conditions, DB_OrderBy(name, query_order))
+	}
+	conditions = append(conditions, DB_Limit(pager.page_size+1))
+
+	rows, err := DB_SELECT_Context(ctx, pager.db, pager.tbl_target, pager.tbl_where, conditions...)
+	if err != nil {
+		return res, err
+	}
+
+	/*
+		One more row than the page size was read, to know whether there is a further page.
+		다음 페이지가 있는지 알기 위해, 페이지 크기보다 한 row 더 읽음.
+	*/
+	has_more := int64(len(rows)) > pager.page_size
+	if true == has_more {
+		rows = rows[:pager.page_size]
+	}
+
+	if true == backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+		res.IsFirst = !has_more
+		res.IsEnd = false
+	} else {
+		res.IsFirst = mark == nil
+		res.IsEnd = !has_more
+	}
+
+	res.Data = rows
+	if 0 < len(rows) {
+		if false == res.IsEnd {
+			res.Bookmark, err = pager.makeBookmark(order_cols, rows[len(rows)-1], false)
+			if err != nil {
+				return res, err
+			}
+		}
+		if false == res.IsFirst {
+			res.PrevBookmark, err = pager.makeBookmark(order_cols, rows[0], true)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (pager *Pager[DB_Table]) makeBookmark(order_cols []db_ColumnInfo, row DB_Table, backward bool) (string, error) {
+	mark := pager_Bookmark{
+		Table:    db_TableName(reflect.TypeOf(row)),
+		Columns:  pager.order_columns,
+		Order:    pager.order,
+		Backward: backward,
+	}
+
+	row_val := reflect.ValueOf(row)
+	for _, col := range order_cols {
+		value, err := pager_EncodeValue(pager_Arg(row_val.FieldByIndex(col.Index)))
+		if err != nil {
+			return "", err
+		}
+		mark.Values = append(mark.Values, value)
+	}
+
+	payload, err := json.Marshal(mark)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(pager_Sign(payload)), nil
+}
+
+func pager_Sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, Pager_BookmarkKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func pager_DecodeBookmark(bookmark string) (*pager_Bookmark, error) {
+	if bookmark == "" {
+		return nil, nil
+	}
+
+	if 0 == len(Pager_BookmarkKey) {
+		return nil, errors.New("[ Pager Error ] Pager_BookmarkKey is not set")
+	}
+
+	payload_str, sign_str, ok := strings.Cut(bookmark, ".")
+	if false == ok {
+		return nil, errors.New("[ Pager Error ] Invalid bookmark format")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payload_str)
+	if err != nil {
+		return nil, errors.New("[ Pager Error ] Invalid bookmark format")
+	}
+
+	sign, err := base64.RawURLEncoding.DecodeString(sign_str)
+	if err != nil || false == hmac.Equal(sign, pager_Sign(payload)) {
+		return nil, errors.New("[ Pager Error ] Bookmark signature mismatch")
+	}
+
+	var mark pager_Bookmark
+	if err = json.Unmarshal(payload, &mark); err != nil {
+		return nil, errors.New("[ Pager Error ] Invalid bookmark format")
+	}
+
+	if len(mark.Values) != len(mark.Columns) {
+		return nil, errors.New("[ Pager Error ] Invalid bookmark format")
+	}
+
+	return &mark, nil
+}
+
+/*
+	Query arg of an ordering value. A time finer than the precision of its DB_TimeCodec is written with every digit,
+	so the next page does not start from a truncated time.
+	정렬 값의 쿼리 인자. DB_TimeCodec 의 정밀도보다 세밀한 시간은 모든 자리수로 기록하여,
+	다음 페이지가 잘린 시간부터 시작하지 않도록 함.
+*/
+func pager_Arg(val reflect.Value) interface{} {
+	if codec, ok := db_CodecOf(val.Type()).(DB_TimeCodec); ok {
+		t := val.Interface().(time.Time)
+		precision := codec.Precision
+		if precision <= 0 || time.Second < precision {
+			precision = time.Second
+		}
+		if false == t.Truncate(precision).Equal(t) {
+			codec.Precision = time.Nanosecond
+		}
+
+		arg, err := codec.Encode(val)
+		if err != nil {
+			logger.Error(err)
+			return nil
+		}
+		return arg
+	}
+
+	return db_ToArg(val)
+}
+
+/*
+	Each bookmark value is kept as text with its type, so it comes back as the same query arg. (ex. "i:3", "f:0.1", "s:abc")
+	각 bookmark 값은 타입과 함께 텍스트로 보관되어, 같은 쿼리 인자로 복원됨. (ex. "i:3", "f:0.1", "s:abc")
+*/
+func pager_EncodeValue(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case int64:
+		return "i:" + strconv.FormatInt(v, 10), nil
+	case uint64:
+		return "u:" + strconv.FormatUint(v, 10), nil
+	case float64:
+		return "f:" + strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return "b:" + strconv.FormatBool(v), nil
+	case string:
+		return "s:" + v, nil
+	case []byte:
+		return "x:" + base64.RawURLEncoding.EncodeToString(v), nil
+	case time.Time:
+		return "t:" + v.Format(time.RFC3339Nano), nil
+	}
+
+	return "", errors.New(fmt.Sprint("[ Pager Error ] Ordering value can not be in a bookmark - ", reflect.TypeOf(arg)))
+}
+
+func pager_DecodeValues(values []string) ([]interface{}, error) {
+	var args []interface{}
+	for _, value := range values {
+		kind, str, _ := strings.Cut(value, ":")
+
+		var arg interface{}
+		var err error
+		switch kind {
+		case "i":
+			arg, err = strconv.ParseInt(str, 10, 64)
+		case "u":
+			arg, err = strconv.ParseUint(str, 10, 64)
+		case "f":
+			arg, err = strconv.ParseFloat(str, 64)
+		case "b":
+			arg, err = strconv.ParseBool(str)
+		case "s":
+			arg = str
+		case "x":
+			arg, err = base64.RawURLEncoding.DecodeString(str)
+		case "t":
+			arg, err = time.Parse(time.RFC3339Nano, str)
+		default:
+			err = errors.New("unknown type")
+		}
+
+		if err != nil {
+			return nil, errors.New("[ Pager Error ] Invalid bookmark format")
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func pager_Reverse(order DB_OrderDir) DB_OrderDir {
+	if order == DB_DESC {
+		return DB_ASC
+	}
+	return DB_DESC
+}
+
+/*
+	Row value comparison of the ordering columns. => (`a`, `b`) < (?, ?)
+	정렬 컬럼들의 row value 비교. => (`a`, `b`) < (?, ?)
+*/
+type pager_KeysetCond struct {
+	columns []string
+	op      string
+	values  []interface{}
+}
+
+func (c pager_KeysetCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	var cols []string
+	var marks []string
+	for _, name := range c.columns {
+		col, err := column(name)
+		if err != nil {
+			return "", nil, err
+		}
+		cols = append(cols, col)
+		marks = append(marks, "?")
+	}
+
+	return "(" + strings.Join(cols, ", ") + ") " + c.op + " (" + strings.Join(marks, ", ") + ")", c.values, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type test_Post struct {
+	PostID     int64 `PK:"true"`
+	Title      string
+	Score      float64
+	CreateTime time.Time
+}
+
+func (test_Post) TableName() string { return "post" }
+
+const test_PostDDL = `CREATE TABLE post (
+	PostID     INTEGER PRIMARY KEY,
+	Title      TEXT NOT NULL,
+	Score      REAL NOT NULL,
+	CreateTime DATETIME NOT NULL
+)`
+
+func test_PostPager(t *testing.T) (*Pager[test_Post], func(bookmark string) *Pager[test_Post]) {
+	t.Helper()
+
+	db := test_OpenSQLite(t, test_PostDDL)
+	for i := 1; i <= 5; i++ {
+		if _, err := db.Exec("INSERT INTO post (PostID, Title, Score, CreateTime) VALUES (?, 'p', 0.1, '2024-01-01 00:00:00')", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tbl_select, tbl_where test_Post
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.PostID = 0
+	tbl_select.Title = ""
+
+	open := func(bookmark string) *Pager[test_Post] {
+		return NewPager(db, tbl_select, tbl_where, []string{"PostID"}, bookmark, 2)
+	}
+	return open(""), open
+}
+
+func test_PostIDs(rows []test_Post) []int64 {
+	var ids []int64
+	for _, row := range rows {
+		ids = append(ids, row.PostID)
+	}
+	return ids
+}
+
+func TestPagerKeyset(t *testing.T) {
+	Pager_BookmarkKey = []byte("test")
+	defer func() { Pager_BookmarkKey = nil }()
+
+	pager, open := test_PostPager(t)
+	first, err := pager.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if false == reflect.DeepEqual([]int64{1, 2}, test_PostIDs(first.Data)) || false == first.IsFirst || true == first.IsEnd {
+		t.Fatalf("first page - %+v", first)
+	}
+
+	second, err := open(first.Bookmark).GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if false == reflect.DeepEqual([]int64{3, 4}, test_PostIDs(second.Data)) || true == second.IsFirst || true == second.IsEnd {
+		t.Fatalf("second page - %+v", second)
+	}
+
+	last, err := open(second.Bookmark).GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if false == reflect.DeepEqual([]int64{5}, test_PostIDs(last.Data)) || false == last.IsEnd {
+		t.Fatalf("last page - %+v", last)
+	}
+
+	prev, err := open(second.PrevBookmark).GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if false == reflect.DeepEqual([]int64{1, 2}, test_PostIDs(prev.Data)) || false == prev.IsFirst {
+		t.Fatalf("previous page - %+v", prev)
+	}
+
+	/*
+		A modified bookmark fails the signature check.
+	*/
+	if _, err = open(first.Bookmark[1:]).GetResult(); err == nil {
+		t.Fatal("modified bookmark was accepted")
+	}
+}
+
+func TestPagerNeedsKey(t *testing.T) {
+	Pager_BookmarkKey = nil
+
+	pager, _ := test_PostPager(t)
+	if _, err := pager.GetResult(); err == nil {
+		t.Fatal("GetResult without Pager_BookmarkKey did not fail")
+	}
+	if _, err := pager_DecodeBookmark("e30.e30"); err == nil {
+		t.Fatal("bookmark was decoded without Pager_BookmarkKey")
+	}
+}
+
+func TestPagerBookmarkPrecision(t *testing.T) {
+	Pager_BookmarkKey = []byte("test")
+	defer func() { Pager_BookmarkKey = nil }()
+
+	var tbl_select test_Post
+	DB_InitTable(&tbl_select)
+	pager := NewPager[test_Post](nil, tbl_select, tbl_select, []string{"CreateTime", "Score", "PostID"}, "", 10)
+
+	var order_cols []db_ColumnInfo
+	for _, name := range pager.order_columns {
+		col, _ := db_FindColumn(reflect.TypeOf(tbl_select), name)
+		order_cols = append(order_cols, col)
+	}
+
+	row := test_Post{PostID: 1 << 60, Score: 0.1 + 0.2, CreateTime: time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)}
+	bookmark, err := pager.makeBookmark(order_cols, row, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mark, err := pager_DecodeBookmark(bookmark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := pager_DecodeValues(mark.Values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{"2024-01-01 00:00:00.123456789", 0.1 + 0.2, int64(1 << 60)}
+	if false == reflect.DeepEqual(want, values) {
+		t.Fatalf("got %#v", values)
+	}
+}
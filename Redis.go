@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Minimal Redis client speaking RESP over a net.Conn, the Go side of Redis.ts.
+	A command is sent as an array of bulk strings, and the reply comes back as
+	string (simple / bulk), int64 (integer), nil (null), []interface{} (array) or RedisError.
+	net.Conn 위에서 RESP 로 통신하는 최소한의 Redis 클라이언트, Redis.ts 의 Go 버전.
+	명령은 bulk string 배열로 보내며, 응답은
+	string (simple / bulk), int64 (integer), nil (null), []interface{} (array) 또는 RedisError 로 돌아옴.
+
+	< 주의점 >
+	1. one command at a time per connection. (no pipelining)
+	2. after an I/O error the connection is closed, dial a new one.
+
+	ex)
+		redis, err := DialRedis(ctx, "127.0.0.1:6379")
+		reply, err := redis.Do(ctx, "ZADD", "rank:1", 100, "player")	<- int64(1)
+*/
+type Redis struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mutex  sync.Mutex
+	broken error
+}
+
+type RedisError string
+
+func (e RedisError) Error() string { return string(e) }
+
+func NewRedis(conn net.Conn) *Redis {
+	return &Redis{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func DialRedis(ctx context.Context, addr string) (*Redis, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedis(conn), nil
+}
+
+func (redis *Redis) Close() error {
+	return redis.conn.Close()
+}
+
+/*
+	Sends one command and reads its reply. A RedisError reply is returned as the error.
+	명령 하나를 보내고 응답을 읽음. RedisError 응답은 error 로 반환.
+*/
+func (redis *Redis) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if 1 > len(args) {
+		return nil, errors.New("[ Redis Error ] No command")
+	}
+
+	redis.mutex.Lock()
+	defer redis.mutex.Unlock()
+
+	if redis.broken != nil {
+		return nil, redis.broken
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	redis.conn.SetDeadline(deadline)
+
+	/*
+		A deadline in the past wakes up a blocked read / write when ctx is canceled.
+		On return the deadline is cleared, or when the func has started, it is waited for,
+		so it never sets the past deadline during the next command.
+		ctx 가 취소되면 지난 시각을 deadline 으로 설정해 막혀 있는 read / write 를 깨움.
+		반환할 때 deadline 을 지우거나, func 가 이미 시작되었으면 끝날 때까지 기다려,
+		다음 명령 도중에 지난 deadline 을 설정하지 않도록 함.
+	*/
+	canceled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		redis.conn.SetDeadline(time.Unix(1, 0))
+		close(canceled)
+	})
+	defer func() {
+		if true == stop() {
+			redis.conn.SetDeadline(time.Time{})
+		} else {
+			<-canceled
+		}
+	}()
+
+	var buf strings.Builder
+	buf.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		str, err := redis_ArgString(arg)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("$" + strconv.Itoa(len(str)) + "\r\n" + str + "\r\n")
+	}
+
+	if _, err := io.WriteString(redis.conn, buf.String()); err != nil {
+		return nil, redis.fail(ctx, err)
+	}
+
+	reply, err := redis_ReadReply(redis.reader)
+	if err != nil {
+		if _, ok := err.(RedisError); ok {
+			return nil, err
+		}
+		return nil, redis.fail(ctx, err)
+	}
+
+	return reply, nil
+}
+
+func (redis *Redis) fail(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	redis.broken = errors.New(fmt.Sprint("[ Redis Error ] Connection is broken - ", err))
+	redis.conn.Close()
+	return err
+}
+
+/*
+	Argument of a command as a bulk string. string, []byte, bool (1 / 0), every integer and float kind, or a fmt.Stringer.
+	A time.Duration is an integer of nanoseconds, so convert it to the unit of the command. (ex. ttl.Milliseconds() for PX)
+	명령 인자를 bulk string 으로 변환. string, []byte, bool (1 / 0), 모든 정수와 실수 kind, 또는 fmt.Stringer.
+	time.Duration 은 나노초 정수이므로, 명령의 단위로 변환해서 사용. (ex. PX 에는 ttl.Milliseconds())
+*/
+func redis_ArgString(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case []byte:
+		return string(v), nil
+	case bool:
+		if true == v {
+			return "1", nil
+		}
+		return "0", nil
+	}
+
+	switch val := reflect.ValueOf(arg); val.Kind() {
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 64), nil
+	}
+
+	if stringer, ok := arg.(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+
+	return "", errors.New(fmt.Sprintf("[ Redis Error ] Unsupported argument type - %T", arg))
+}
+
+/*
+	Checks the arguments of the commands, so an argument Redis can not send fails at ADD_REDIS and not after commit.
+	명령의 인자들을 확인하므로, Redis 로 보낼 수 없는 인자는 commit 후가 아닌 ADD_REDIS 에서 실패함.
+*/
+func redis_CheckArgs(commands ...[]interface{}) error {
+	for _, command := range commands {
+		for _, arg := range command {
+			if _, err := redis_ArgString(arg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func redis_ReadReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New(fmt.Sprint("[ Redis Error ] Invalid reply - ", strconv.Quote(line)))
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+
+	case '-':
+		return nil, RedisError(body)
+
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if 0 > size {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if 0 > count {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			items[i], err = redis_ReadReply(reader)
+			if err != nil {
+				if _, ok := err.(RedisError); false == ok {
+					return nil, err
+				}
+				items[i] = err
+			}
+		}
+		return items, nil
+	}
+
+	return nil, errors.New(fmt.Sprint("[ Redis Error ] Invalid reply - ", strconv.Quote(line)))
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+In process RESP server on net.Pipe. reply answers each command with raw RESP, "" answers nothing.
+net.Pipe 위의 RESP 서버. reply 는 각 명령에 raw RESP 로 응답하며, "" 이면 응답하지 않음.
+*/
+type test_RedisServer struct {
+	mutex    sync.Mutex
+	commands []string
+}
+
+func test_OpenRedis(t *testing.T, reply func(args []string) string) (*Redis, *test_RedisServer) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	fake := &test_RedisServer{}
+	redis := NewRedis(client)
+	t.Cleanup(func() {
+		redis.Close()
+		server.Close()
+	})
+
+	go func() {
+		reader := bufio.NewReader(server)
+		for {
+			args, err := test_ReadCommand(reader)
+			if err != nil {
+				return
+			}
+
+			fake.mutex.Lock()
+			fake.commands = append(fake.commands, strings.Join(args, " "))
+			fake.mutex.Unlock()
+
+			if answer := reply(args); answer != "" {
+				if _, err = io.WriteString(server, answer); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return redis, fake
+}
+
+func (fake *test_RedisServer) Commands() []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return append([]string(nil), fake.commands...)
+}
+
+func test_ReadCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+
+	return args, nil
+}
+
+func TestRedisReplies(t *testing.T) {
+	replies := map[string]string{
+		"PING":   "+PONG\r\n",
+		"INCR":   ":3\r\n",
+		"GET":    "$5\r\nhello\r\n",
+		"NONE":   "$-1\r\n",
+		"LRANGE": "*3\r\n*2\r\n:1\r\n$1\r\na\r\n$-1\r\n-ERR inner\r\n",
+		"BAD":    "-ERR unknown command\r\n",
+	}
+	redis, fake := test_OpenRedis(t, func(args []string) string { return replies[args[0]] })
+	ctx := context.Background()
+
+	tests := []struct {
+		command []interface{}
+		want    interface{}
+	}{
+		{[]interface{}{"PING"}, "PONG"},
+		{[]interface{}{"INCR", "count"}, int64(3)},
+		{[]interface{}{"GET", "key"}, "hello"},
+		{[]interface{}{"NONE"}, nil},
+		{[]interface{}{"LRANGE", "list", 0, int64(-1)}, []interface{}{[]interface{}{int64(1), "a"}, nil, RedisError("ERR inner")}},
+	}
+	for _, test := range tests {
+		reply, err := redis.Do(ctx, test.command...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if false == reflect.DeepEqual(test.want, reply) {
+			t.Fatalf("%v - got %#v", test.command, reply)
+		}
+	}
+
+	/*
+		An error reply is the error, and the connection is still usable.
+	*/
+	_, err := redis.Do(ctx, "BAD")
+	var redis_err RedisError
+	if false == errors.As(err, &redis_err) || "ERR unknown command" != string(redis_err) {
+		t.Fatalf("got %v", err)
+	}
+	if reply, err := redis.Do(ctx, "PING"); err != nil || "PONG" != reply {
+		t.Fatalf("after error reply - %v, %v", reply, err)
+	}
+
+	if "LRANGE list 0 -1" != fake.Commands()[4] {
+		t.Fatalf("got %q", fake.Commands())
+	}
+}
+
+func TestRedisContext(t *testing.T) {
+	redis, fake := test_OpenRedis(t, func(args []string) string {
+		if "BLOCK" == args[0] {
+			return ""
+		}
+		return "+OK\r\n"
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := redis.Do(canceled, "SET", "k", "v"); false == errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+	if 0 != len(fake.Commands()) {
+		t.Fatalf("command was sent with a canceled ctx - %q", fake.Commands())
+	}
+
+	/*
+		A finished ctx does not leave its deadline on the connection.
+	*/
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	if _, err := redis.Do(short, "SET", "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	if reply, err := redis.Do(context.Background(), "SET", "k", "v"); err != nil || "OK" != reply {
+		t.Fatalf("got %v, %v", reply, err)
+	}
+
+	/*
+		Canceling a blocked read returns the ctx error and breaks the connection.
+	*/
+	blocked, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := redis.Do(blocked, "BLOCK"); false == errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := redis.Do(context.Background(), "PING"); err == nil {
+		t.Fatal("broken connection was used")
+	}
+}
+
+func TestRedisJobCompensation(t *testing.T) {
+	redis, fake := test_OpenRedis(t, func(args []string) string {
+		if "FAIL" == args[0] {
+			return "-ERR failed\r\n"
+		}
+		return ":1\r\n"
+	})
+	db := test_OpenSQLite(t, test_ProfileDDL)
+
+	var tbl_insert test_Profile
+	DB_InitTable(&tbl_insert)
+	tbl_insert.ProfileID = 1
+	tbl_insert.Name = "alice"
+
+	/*
+		The third Redis job fails, so the compensations of the first two run in reverse order.
+	*/
+	var dbjob DBJob
+	ADD_INSERT(&dbjob, tbl_insert)
+	ADD_REDIS(&dbjob, redis, []interface{}{"ZADD", "rank", 1, "a"}, []interface{}{"ZREM", "rank", "a"})
+	ADD_REDIS(&dbjob, redis, []interface{}{"SADD", "set", "a"}, []interface{}{"SREM", "set", "a"})
+	ADD_REDIS(&dbjob, redis, []interface{}{"FAIL"}, []interface{}{"NEVER"})
+	if _, err := dbjob.Run(db); err == nil {
+		t.Fatal("failed Redis job did not fail Run")
+	}
+
+	want := []string{"ZADD rank 1 a", "SADD set a", "FAIL", "SREM set a", "ZREM rank a"}
+	if false == reflect.DeepEqual(want, fake.Commands()) {
+		t.Fatalf("got %q", fake.Commands())
+	}
+
+	/*
+		The SQL jobs are committed before the Redis jobs, and stay.
+	*/
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM profile").Scan(&count); err != nil || 1 != count {
+		t.Fatalf("count %v - %v", count, err)
+	}
+}
+
+type test_Rank int32
+
+type test_Member struct{ id int }
+
+func (m test_Member) String() string { return "member:" + strconv.Itoa(m.id) }
+
+func TestRedisArgs(t *testing.T) {
+	redis, fake := test_OpenRedis(t, func(args []string) string { return ":1\r\n" })
+
+	/*
+		Every integer and float kind, named types and fmt.Stringer are sent as their text.
+	*/
+	command := []interface{}{"ZADD", "k", int32(-3), uint(4), uint32(5), float32(1.5), test_Rank(7), time.Second, test_Member{id: 9}}
+	if _, err := redis.Do(context.Background(), command...); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ZADD k -3 4 5 1.5 7 1000000000 member:9"}
+	if false == reflect.DeepEqual(want, fake.Commands()) {
+		t.Fatalf("got %q", fake.Commands())
+	}
+
+	/*
+		An argument that can not be sent fails at ADD_REDIS, in the command or in the compensation.
+	*/
+	var dbjob DBJob
+	if err := ADD_REDIS(&dbjob, redis, []interface{}{"SET", "k", struct{}{}}, nil); err == nil {
+		t.Fatal("ADD_REDIS with a struct argument did not fail")
+	}
+	if err := ADD_REDIS(&dbjob, redis, []interface{}{"SET", "k", 1}, []interface{}{"DEL", []string{"k"}}); err == nil {
+		t.Fatal("ADD_REDIS with a slice compensation argument did not fail")
+	}
+	if 0 != len(dbjob.queryList) {
+		t.Fatalf("%v jobs added", len(dbjob.queryList))
+	}
+}
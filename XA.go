@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+	DBJob across several named MySQL databases, committed together with XA (two-phase commit).
+	Each database gets its own DBJob (a branch), Run runs every branch in XA START ~ XA END, prepares all of them,
+	writes the commit decision to the log database, and then XA COMMITs them.
+	If a process dies after the decision, DB_XARecover finishes the prepared branches from the decision log.
+	이름 붙은 여러 MySQL DB 에 걸친 DBJob 으로, XA (2단계 commit) 로 함께 commit 됨.
+	DB 마다 자기 DBJob (branch) 을 가지며, Run 은 모든 branch 를 XA START ~ XA END 에서 실행하고, 모두 prepare 한 후,
+	commit 결정을 로그 DB 에 기록하고 나서 XA COMMIT 함.
+	결정 이후에 프로세스가 죽으면, DB_XARecover 가 결정 로그로 prepare 된 branch 를 마무리.
+
+	< 주의점 >
+	1. MySQL only. The decision log table must exist in the log database.
+		CREATE TABLE db_xa_log (
+			XID        VARCHAR(64) PRIMARY KEY,
+			Decision   VARCHAR(16) NOT NULL,
+			Branches   TEXT NOT NULL,
+			CreateTime DATETIME NOT NULL
+		);
+	2. branches run in the XA transaction of Run, so SetIsolation / SetRetry / ADD_REDIS of a branch can not be used.
+	3. call DB_XARecover on server start, and from time to time, with every database of the branches.
+
+	ex)
+		var xa DB_XAJob
+		xa.LogDB = "account"
+		ADD_UPDATE(xa.Job("account"), tbl_account, tbl_account_where)
+		ADD_INSERT(xa.Job(fmt.Sprint("game", tbl_account.GameDBID)), tbl_character)
+		affected, err := xa.Run(map[string]*sql.DB{"account": accountDB, "game3": gameDB3})
+*/
+type DB_XAJob struct {
+	LogDB    string
+	branches []db_XABranch
+}
+
+type db_XABranch struct {
+	name string
+	job  *DBJob
+}
+
+type DB_XALog struct {
+	XID        string `PK:"true"`
+	Decision   string
+	Branches   string
+	CreateTime time.Time
+}
+
+func (DB_XALog) TableName() string { return "db_xa_log" }
+
+const (
+	DB_XA_PENDING = "PENDING"
+	DB_XA_COMMIT  = "COMMIT"
+	DB_XA_ABORT   = "ABORT"
+
+	db_XA_PREFIX = "ezdb-"
+)
+
+/*
+	The DBJob of the named database. The same name gives the same DBJob.
+	이름 붙은 DB 의 DBJob. 같은 이름이면 같은 DBJob.
+*/
+func (xa *DB_XAJob) Job(name string) *DBJob {
+	for _, branch := range xa.branches {
+		if branch.name == name {
+			return branch.job
+		}
+	}
+
+	branch := db_XABranch{name: name, job: &DBJob{dialect: DB_MySQL}}
+	xa.branches = append(xa.branches, branch)
+	return branch.job
+}
+
+func (xa *DB_XAJob) Run(dbs map[string]*sql.DB) (int64, error) {
+	return xa.RunContext(context.Background(), dbs)
+}
+
+/*
+	An executor without BeginTx, so DBJob runs the jobs in the XA transaction of the connection.
+	BeginTx 가 없는 executor 로, DBJob 이 연결의 XA 트랜잭션 안에서 job 을 실행하게 함.
+*/
+type db_XAExecutor struct {
+	DB_Executor
+}
+
+type db_XAState struct {
+	name     string
+	conn     *sql.Conn
+	started  bool
+	ended    bool
+	prepared bool
+	finished bool
+}
+
+func (xa *DB_XAJob) RunContext(ctx context.Context, dbs map[string]*sql.DB) (int64, error) {
+	if 1 > len(xa.branches) {
+		logger.Error("[ DBJob Error ] XA Run Failed. No Jobs")
+		return 0, errors.New("[ DBJob Error ] XA Run Failed. No Jobs")
+	}
+
+	log_db, ok := dbs[xa.LogDB]
+	if false == ok {
+		logger.Error("[ DBJob Error ] XA Run Failed. Unknown log database - ", xa.LogDB)
+		return 0, errors.New(fmt.Sprint("[ DBJob Error ] XA Run Failed. Unknown log database - ", xa.LogDB))
+	}
+
+	var names []string
+	for _, branch := range xa.branches {
+		db, ok := dbs[branch.name]
+		if false == ok {
+			logger.Error("[ DBJob Error ] XA Run Failed. Unknown database - ", branch.name)
+			return 0, errors.New(fmt.Sprint("[ DBJob Error ] XA Run Failed. Unknown database - ", branch.name))
+		}
+		if false == db_IsIdentifier(branch.name) {
+			logger.Error("[ DBJob Error ] XA Run Failed. Invalid database name - ", branch.name)
+			return 0, errors.New(fmt.Sprint("[ DBJob Error ] XA Run Failed. Invalid database name - ", branch.name))
+		}
+		if d := db_DialectOf(db); d.Name() != "mysql" {
+			logger.Error("[ DBJob Error ] XA Run Failed. XA needs mysql - ", branch.name, " is ", d.Name())
+			return 0, errors.New(fmt.Sprint("[ DBJob Error ] XA Run Failed. XA needs mysql - ", branch.name, " is ", d.Name()))
+		}
+		names = append(names, branch.name)
+	}
+
+	xid, err := db_XANewID()
+	if err != nil {
+		return 0, err
+	}
+
+	/*
+		The log row is written before any branch, so DB_XARecover can tell a running XA from a dead one.
+		어떤 branch 보다 먼저 로그 row 를 기록하여, DB_XARecover 가 실행 중인 XA 와 죽은 XA 를 구분할 수 있게 함.
+	*/
+	var log_row DB_XALog
+	DB_InitTable(&log_row)
+	log_row.XID = xid
+	log_row.Decision = DB_XA_PENDING
+	log_row.Branches = strings.Join(names, ",")
+	log_row.CreateTime = time.Now()
+	if _, err = DB_INSERT_Context(ctx, log_db, log_row); err != nil {
+		return 0, err
+	}
+
+	states := make([]*db_XAState, len(xa.branches))
+	defer func() {
+		for _, state := range states {
+			if state == nil || state.conn == nil {
+				continue
+			}
+
+			/*
+				A connection left in an XA state is thrown away, not given back to the pool.
+				XA 상태로 남은 연결은 pool 로 돌려주지 않고 버림.
+			*/
+			if true == state.started && false == state.finished {
+				state.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+			state.conn.Close()
+		}
+	}()
+
+	/*
+		Phase 1 : run and prepare every branch.
+		1 단계 : 모든 branch 를 실행하고 prepare.
+	*/
+	var affCount int64 = 0
+	for i, branch := range xa.branches {
+		states[i] = &db_XAState{name: branch.name}
+		affected, err := xa.runBranch(ctx, dbs[branch.name], xid, branch, states[i])
+		if err != nil {
+			logger.Errorf("[ DBJob ERROR ] XA %v branch %v failed - %v", xid, branch.name, err)
+			return 0, xa.abort(log_db, xid, states, err)
+		}
+		affCount += affected
+	}
+
+	/*
+		Commit point : only one of this Run (PENDING -> COMMIT) and DB_XARecover (PENDING -> ABORT) can win.
+		When the result is unknown, the branches are left prepared for DB_XARecover.
+		commit 시점 : 이 Run (PENDING -> COMMIT) 과 DB_XARecover (PENDING -> ABORT) 중 하나만 성공할 수 있음.
+		결과를 알 수 없으면, branch 들은 DB_XARecover 를 위해 prepare 상태로 남겨둠.
+	*/
+	decided, err := db_XADecide(ctx, log_db, xid, DB_XA_COMMIT)
+	if err != nil {
+		logger.Errorf("[ DBJob ERROR ] XA %v decision unknown, left for DB_XARecover - %v", xid, err)
+		return 0, err
+	}
+	if false == decided {
+		return 0, xa.abort(log_db, xid, states, errors.New(fmt.Sprint("[ DBJob Error ] XA ", xid, " was aborted by DB_XARecover")))
+	}
+
+	/*
+		Phase 2 : the decision is made, a branch failing to commit here is committed later by DB_XARecover.
+		2 단계 : 결정이 끝났으며, 여기서 commit 에 실패한 branch 는 나중에 DB_XARecover 가 commit 함.
+	*/
+	all_done := true
+	for _, state := range states {
+		if _, err = state.conn.ExecContext(context.WithoutCancel(ctx), "XA COMMIT "+db_XAQuote(xid, state.name)+";"); err != nil {
+			logger.Errorf("[ DBJob ERROR ] XA %v branch %v commit failed, left for DB_XARecover - %v", xid, state.name, err)
+			all_done = false
+			continue
+		}
+		state.finished = true
+	}
+
+	if true == all_done {
+		db_XADeleteLog(log_db, xid)
+	}
+
+	return affCount, nil
+}
+
+func (xa *DB_XAJob) runBranch(ctx context.Context, db *sql.DB, xid string, branch db_XABranch, state *db_XAState) (int64, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	state.conn = conn
+
+	xid_str := db_XAQuote(xid, branch.name)
+	if _, err = conn.ExecContext(ctx, "XA START "+xid_str+";"); err != nil {
+		return 0, err
+	}
+	state.started = true
+
+	affected, err := branch.job.RunContext(ctx, DB_WithDialect(db_XAExecutor{conn}, db_DialectOf(db)))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = conn.ExecContext(ctx, "XA END "+xid_str+";"); err != nil {
+		return 0, err
+	}
+	state.ended = true
+
+	if _, err = conn.ExecContext(ctx, "XA PREPARE "+xid_str+";"); err != nil {
+		return 0, err
+	}
+	state.prepared = true
+
+	return affected, nil
+}
+
+/*
+	Rolls back every started branch. The decision is ABORT first, so a branch failing to roll back is rolled back by DB_XARecover.
+	시작된 모든 branch 를 롤백. 먼저 ABORT 로 결정하므로, 롤백에 실패한 branch 는 DB_XARecover 가 롤백함.
+*/
+func (xa *DB_XAJob) abort(log_db *sql.DB, xid string, states []*db_XAState, cause error) error {
+	ctx := context.Background()
+	if _, err := db_XADecide(ctx, log_db, xid, DB_XA_ABORT); err != nil {
+		logger.Errorf("[ DBJob ERROR ] XA %v abort decision failed - %v", xid, err)
+	}
+
+	all_done := true
+	for _, state := range states {
+		if state == nil || false == state.started {
+			continue
+		}
+
+		xid_str := db_XAQuote(xid, state.name)
+		if false == state.ended {
+			if _, err := state.conn.ExecContext(ctx, "XA END "+xid_str+";"); err != nil {
+				logger.Errorf("[ DBJob ERROR ] XA %v branch %v end failed - %v", xid, state.name, err)
+			}
+		}
+		if _, err := state.conn.ExecContext(ctx, "XA ROLLBACK "+xid_str+";"); err != nil {
+			logger.Errorf("[ DBJob ERROR ] XA %v branch %v rollback failed - %v", xid, state.name, err)
+			if true == state.prepared {
+				all_done = false
+			}
+			continue
+		}
+		state.finished = true
+	}
+
+	if true == all_done {
+		db_XADeleteLog(log_db, xid)
+	}
+
+	return cause
+}
+
+/*
+	Resolves the prepared XA branches left by a dead DB_XAJob.Run, from the decision log in log_db.
+	COMMIT commits, ABORT rolls back, and PENDING older than timeout is aborted. (a younger one may still be running)
+	A prepared branch without a log row is left prepared and returned as an error, a missing decision is not an abort.
+	A decided log row is deleted only when XA RECOVER ran on every database of its Branches, and none of them still has it.
+	죽은 DB_XAJob.Run 이 남긴 prepare 된 XA branch 를, log_db 의 결정 로그로 마무리.
+	COMMIT 은 commit, ABORT 는 롤백, timeout 보다 오래된 PENDING 은 abort. (더 최근 것은 아직 실행 중일 수 있음)
+	로그 row 가 없는 prepare 된 branch 는 그대로 두고 에러로 반환, 결정이 없다는 것이 abort 는 아님.
+	결정된 로그 row 는 Branches 의 모든 DB 에서 XA RECOVER 를 실행했고, 어디에도 남아 있지 않을 때만 삭제.
+*/
+func DB_XARecover(ctx context.Context, dbs map[string]*sql.DB, log_db string, timeout time.Duration) error {
+	logs, ok := dbs[log_db]
+	if false == ok {
+		return errors.New(fmt.Sprint("[ DBJob Error ] XA Recover Failed. Unknown log database - ", log_db))
+	}
+
+	/*
+		Decided log rows are read before XA RECOVER, so every branch of them was already prepared or finished when scanned.
+		결정된 로그 row 는 XA RECOVER 전에 읽으므로, 스캔 시점에 그 branch 들은 이미 prepare 되었거나 끝난 상태.
+	*/
+	var errs []error
+	var tbl_select, tbl_where DB_XALog
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.XID = ""
+	tbl_select.Decision = ""
+	tbl_select.Branches = ""
+	decided, err := DB_SELECT_Context(ctx, logs, tbl_select, tbl_where, DB_In("Decision", DB_XA_COMMIT, DB_XA_ABORT))
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	scanned := make(map[string]bool)
+	remain := make(map[string]bool)
+	for name, db := range dbs {
+		branches, err := db_XAPrepared(ctx, db)
+		if err != nil {
+			logger.Errorf("[ DBJob ERROR ] XA RECOVER on %v failed - %v", name, err)
+			errs = append(errs, err)
+			continue
+		}
+		scanned[name] = true
+
+		for _, branch := range branches {
+			decision, err := db_XADecision(ctx, logs, branch[0], timeout)
+			if err != nil {
+				errs = append(errs, err)
+				remain[branch[0]] = true
+				continue
+			}
+			if decision == DB_XA_PENDING {
+				remain[branch[0]] = true
+				continue
+			}
+
+			command := "XA ROLLBACK "
+			if decision == DB_XA_COMMIT {
+				command = "XA COMMIT "
+			}
+			if _, err = db.ExecContext(ctx, command+db_XAQuote(branch[0], branch[1])+";"); err != nil {
+				logger.Errorf("[ DBJob ERROR ] XA %v branch %v %v failed - %v", branch[0], branch[1], command, err)
+				errs = append(errs, err)
+				remain[branch[0]] = true
+				continue
+			}
+			logger.Info("[ DBJob ] XA ", branch[0], " branch ", branch[1], " recovered - ", command)
+		}
+	}
+
+	for _, row := range decided {
+		if true == remain[row.XID] {
+			continue
+		}
+
+		all_scanned := true
+		for _, name := range strings.Split(row.Branches, ",") {
+			if false == scanned[name] {
+				all_scanned = false
+				break
+			}
+		}
+		if true == all_scanned {
+			db_XADeleteLog(logs, row.XID)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+/*
+	Prepared branches of this package in db, as [gtrid, bqual].
+	db 에 있는 이 패키지의 prepare 된 branch 들, [gtrid, bqual] 형태.
+*/
+func db_XAPrepared(ctx context.Context, db *sql.DB) ([][2]string, error) {
+	rows, err := db.QueryContext(ctx, "XA RECOVER;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var branches [][2]string
+	for rows.Next() {
+		var format_id, gtrid_len, bqual_len int64
+		var data []byte
+		if err = rows.Scan(&format_id, &gtrid_len, &bqual_len, &data); err != nil {
+			return nil, err
+		}
+		if int64(len(data)) < gtrid_len+bqual_len {
+			continue
+		}
+
+		gtrid := string(data[:gtrid_len])
+		if false == strings.HasPrefix(gtrid, db_XA_PREFIX) {
+			continue
+		}
+		branches = append(branches, [2]string{gtrid, string(data[gtrid_len : gtrid_len+bqual_len])})
+	}
+
+	return branches, rows.Err()
+}
+
+/*
+	Decision for a prepared branch. A PENDING log older than timeout is turned to ABORT first. No log is an error.
+	prepare 된 branch 에 대한 결정. timeout 보다 오래된 PENDING 로그는 먼저 ABORT 로 바꿈. 로그가 없으면 에러.
+*/
+func db_XADecision(ctx context.Context, logs *sql.DB, xid string, timeout time.Duration) (string, error) {
+	var tbl_select, tbl_where DB_XALog
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.Decision = ""
+	tbl_select.CreateTime = time.Now()
+	tbl_where.XID = xid
+
+	rows, err := DB_SELECT_Context(ctx, logs, tbl_select, tbl_where)
+	if err != nil {
+		return "", err
+	}
+	if 0 == len(rows) {
+		logger.Error("[ DBJob Error ] XA ", xid, " is prepared but has no decision log, left prepared")
+		return "", errors.New(fmt.Sprint("[ DBJob Error ] XA ", xid, " is prepared but has no decision log, left prepared"))
+	}
+	if rows[0].Decision != DB_XA_PENDING {
+		return rows[0].Decision, nil
+	}
+	if time.Since(rows[0].CreateTime) < timeout {
+		return DB_XA_PENDING, nil
+	}
+
+	decided, err := db_XADecide(ctx, logs, xid, DB_XA_ABORT)
+	if err != nil {
+		return "", err
+	}
+	if false == decided {
+		/*
+			Run made its decision just now, read it again.
+			Run 이 방금 결정했으므로, 다시 읽음.
+		*/
+		return db_XADecision(ctx, logs, xid, timeout)
+	}
+
+	return DB_XA_ABORT, nil
+}
+
+/*
+	PENDING -> decision. false when the log is not PENDING anymore.
+	PENDING -> decision. 로그가 더 이상 PENDING 이 아니면 false.
+*/
+func db_XADecide(ctx context.Context, logs *sql.DB, xid string, decision string) (bool, error) {
+	var tbl_target, tbl_where DB_XALog
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Decision = decision
+	tbl_where.XID = xid
+	tbl_where.Decision = DB_XA_PENDING
+
+	affected, err := DB_UPDATE_Context(ctx, logs, tbl_target, tbl_where)
+	return 0 < affected, err
+}
+
+func db_XADeleteLog(logs *sql.DB, xid string) {
+	var tbl_where DB_XALog
+	DB_InitTable(&tbl_where)
+	tbl_where.XID = xid
+
+	if _, err := DB_DELETE(logs, tbl_where); err != nil {
+		logger.Errorf("[ DBJob ERROR ] XA %v log delete failed - %v", xid, err)
+	}
+}
+
+func db_XANewID() (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%v%x-%v", db_XA_PREFIX, time.Now().UnixNano(), hex.EncodeToString(random)), nil
+}
+
+/*
+	'gtrid','bqual'. Both are made of [0-9A-Za-z_-], so no escaping is needed.
+	'gtrid','bqual'. 둘 다 [0-9A-Za-z_-] 로만 이루어져 있어 escape 가 필요 없음.
+*/
+func db_XAQuote(gtrid string, bqual string) string {
+	return "'" + gtrid + "','" + bqual + "'"
+}
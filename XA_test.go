@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+Decision log on a fake database, and fake branch databases answering XA RECOVER with their prepared xids.
+가짜 DB 위의 결정 로그와, prepare 된 xid 로 XA RECOVER 에 응답하는 가짜 branch DB 들.
+*/
+type test_XALogs struct {
+	mutex sync.Mutex
+	rows  map[string]DB_XALog
+}
+
+func test_OpenXALogs(t *testing.T, rows ...DB_XALog) (*sql.DB, *test_XALogs) {
+	t.Helper()
+
+	db, fake := test_OpenFake(t, DB_MySQL)
+	logs := &test_XALogs{rows: make(map[string]DB_XALog)}
+	for _, row := range rows {
+		logs.rows[row.XID] = row
+	}
+
+	fake.query = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		logs.mutex.Lock()
+		defer logs.mutex.Unlock()
+
+		var values [][]driver.Value
+		switch {
+		case strings.HasPrefix(query, "XA RECOVER"):
+			return []string{"formatID", "gtrid_length", "bqual_length", "data"}, nil, nil
+		case strings.Contains(query, "`Decision` IN"):
+			for _, row := range logs.rows {
+				if row.Decision != DB_XA_PENDING {
+					values = append(values, []driver.Value{row.XID, row.Decision, row.Branches})
+				}
+			}
+			return []string{"XID", "Decision", "Branches"}, values, nil
+		default:
+			if row, ok := logs.rows[args[0].Value.(string)]; ok {
+				values = append(values, []driver.Value{row.Decision, row.CreateTime})
+			}
+			return []string{"Decision", "CreateTime"}, values, nil
+		}
+	}
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) {
+		logs.mutex.Lock()
+		defer logs.mutex.Unlock()
+
+		if strings.HasPrefix(query, "DELETE") {
+			delete(logs.rows, args[0].Value.(string))
+		}
+		return 1, nil
+	}
+
+	return db, logs
+}
+
+func (logs *test_XALogs) XIDs() []string {
+	logs.mutex.Lock()
+	defer logs.mutex.Unlock()
+
+	var xids []string
+	for xid := range logs.rows {
+		xids = append(xids, xid)
+	}
+	sort.Strings(xids)
+	return xids
+}
+
+/*
+branch database with its prepared [gtrid, bqual], XA COMMIT / XA ROLLBACK finish them.
+prepare 된 [gtrid, bqual] 을 가진 branch DB, XA COMMIT / XA ROLLBACK 으로 끝남.
+*/
+func test_OpenXABranch(t *testing.T, prepared ...[2]string) (*sql.DB, *test_FakeDB) {
+	t.Helper()
+
+	db, fake := test_OpenFake(t, DB_MySQL)
+	var mutex sync.Mutex
+	fake.query = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		var values [][]driver.Value
+		for _, branch := range prepared {
+			values = append(values, []driver.Value{int64(1), int64(len(branch[0])), int64(len(branch[1])), []byte(branch[0] + branch[1])})
+		}
+		return []string{"formatID", "gtrid_length", "bqual_length", "data"}, values, nil
+	}
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for i, branch := range prepared {
+			if strings.Contains(query, db_XAQuote(branch[0], branch[1])) {
+				prepared = append(prepared[:i:i], prepared[i+1:]...)
+				break
+			}
+		}
+		return 0, nil
+	}
+
+	return db, fake
+}
+
+func TestXARecover(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	log_db, logs := test_OpenXALogs(t,
+		DB_XALog{XID: "ezdb-1", Decision: DB_XA_COMMIT, Branches: "game1,game2", CreateTime: old},
+		DB_XALog{XID: "ezdb-2", Decision: DB_XA_ABORT, Branches: "game1,game3", CreateTime: old},
+	)
+	game1, fake1 := test_OpenXABranch(t, [2]string{"ezdb-1", "game1"})
+	game2, fake2 := test_OpenXABranch(t, [2]string{"ezdb-1", "game2"}, [2]string{"ezdb-3", "game2"})
+
+	err := DB_XARecover(context.Background(), map[string]*sql.DB{"account": log_db, "game1": game1, "game2": game2}, "account", time.Minute)
+
+	/*
+		ezdb-3 has no log, so it is an error and left prepared, not rolled back.
+	*/
+	if err == nil || false == strings.Contains(err.Error(), "ezdb-3") {
+		t.Fatalf("got %v", err)
+	}
+	if false == reflect.DeepEqual([]string{"XA RECOVER;", "XA COMMIT 'ezdb-1','game1';"}, fake1.Statements()) {
+		t.Fatalf("game1 - %q", fake1.Statements())
+	}
+	if false == reflect.DeepEqual([]string{"XA RECOVER;", "XA COMMIT 'ezdb-1','game2';"}, fake2.Statements()) {
+		t.Fatalf("game2 - %q", fake2.Statements())
+	}
+
+	/*
+		ezdb-1 is finished on every branch. game3 of ezdb-2 was not scanned, so its log stays.
+	*/
+	if false == reflect.DeepEqual([]string{"ezdb-2"}, logs.XIDs()) {
+		t.Fatalf("log rows - %v", logs.XIDs())
+	}
+}
+
+func TestXARecoverUnscanned(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	log_db, logs := test_OpenXALogs(t, DB_XALog{XID: "ezdb-1", Decision: DB_XA_COMMIT, Branches: "game1,game2", CreateTime: old})
+	game1, _ := test_OpenXABranch(t)
+	game2, fake2 := test_OpenXABranch(t, [2]string{"ezdb-1", "game2"})
+	fake2.query = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return nil, nil, driver.ErrBadConn
+	}
+
+	/*
+		XA RECOVER of game2 fails, so the branch of game2 may still be prepared and the log is kept.
+	*/
+	if err := DB_XARecover(context.Background(), map[string]*sql.DB{"account": log_db, "game1": game1, "game2": game2}, "account", time.Minute); err == nil {
+		t.Fatal("failed XA RECOVER was not returned")
+	}
+	if false == reflect.DeepEqual([]string{"ezdb-1"}, logs.XIDs()) {
+		t.Fatalf("log rows - %v", logs.XIDs())
+	}
+}
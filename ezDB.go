@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
 )
 
 type tblaccount struct {
@@ -26,1107 +31,4782 @@ type tblaccount struct {
 
 const (
 	DB_UNUSE_STRING = "\t\n0"
+	DB_NULL_STRING  = "\t\n1"
 )
 
-func db_IsUse(val reflect.Value) bool {
-	v := val
-	k := v.Kind()
+/*
+	Column wrapper that remembers whether a value was set, instead of DB_UNUSE_STRING / math.MaxInt sentinel values.
+	The zero value is "not set", so DB_InitTable is not needed and MaxInt64 or "\t\n0" can be written as real values.
+	sentinel 값 대신 값이 설정되었는지를 직접 기억하는 컬럼 래퍼.
+	zero value 가 "설정 안됨" 이므로 DB_InitTable 이 필요 없고, MaxInt64 나 "\t\n0" 도 실제 값으로 사용 가능.
 
-	switch k {
-	case reflect.String:
-		return v.String() != DB_UNUSE_STRING
-
-	case reflect.Int:
-		return v.Int() != math.MaxInt
-	case reflect.Int8:
-		return v.Int() != math.MaxInt8
-	case reflect.Int16:
-		return v.Int() != math.MaxInt16
-	case reflect.Int32:
-		return v.Int() != math.MaxInt32
-	case reflect.Int64:
-		return v.Int() != math.MaxInt64
-	case reflect.Uint:
-		return v.Uint() != math.MaxUint
-	case reflect.Uint8:
-		return v.Uint() != math.MaxUint8
-	case reflect.Uint16:
-		return v.Uint() != math.MaxUint16
-	case reflect.Uint32:
-		return v.Uint() != math.MaxUint32
-	case reflect.Uint64:
-		return v.Uint() != math.MaxUint64
-
-	case reflect.Float32, reflect.Complex64:
-		return v.Float() != math.MaxFloat32
-	case reflect.Float64, reflect.Complex128:
-		return v.Float() != math.MaxFloat64
-
-	case reflect.TypeOf(time.Time{}).Kind():
-		return v.Interface().(time.Time).Format("2006-01-02 15:04:05") != time.Time{}.Format("2006-01-02 15:04:05") //time.RFC3339Nano)
-	}
+	ex)
+		type tblcharacter struct {
+			CharUID  DB_Field[int64] `PK:"true"`
+			UserUUID DB_Field[int64]
+			Gold     DB_Field[int64]
+		}
 
-	return false
+		var tbl_target, tbl_where tblcharacter
+		tbl_target.Gold.Set(math.MaxInt64)
+		tbl_where.CharUID = DB_Set[int64](10)
+		DB_UPDATE(db, tbl_target, tbl_where)	<- UPDATE tblcharacter SET `Gold`=? WHERE `CharUID` = ?;
+*/
+type DB_Field[T interface{}] struct {
+	val   T
+	state db_FieldState
 }
 
-func db_ToString(val reflect.Value) string {
-	v := val
-	k := v.Kind()
+type db_FieldState int
 
-	switch k {
-	case reflect.String:
-		return "\"" + v.String() + "\""
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return fmt.Sprint(v.Int())
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return fmt.Sprint(v.Uint())
+const (
+	db_FIELD_UNSET db_FieldState = iota
+	db_FIELD_VALUE
+	db_FIELD_NULL
+	db_FIELD_NOT_NULL
+)
 
-	case reflect.Float32, reflect.Complex64, reflect.Float64, reflect.Complex128:
-		return fmt.Sprint(v.Float())
+type db_Column interface {
+	db_State() db_FieldState
+	db_Value() reflect.Value
+}
 
-	case reflect.TypeOf(time.Time{}).Kind():
-		return "\"" + v.Interface().(time.Time).Format("2006-01-02 15:04:05") + "\"" //time.RFC3339Nano)
-	}
+func DB_Set[T interface{}](v T) DB_Field[T] {
+	return DB_Field[T]{val: v, state: db_FIELD_VALUE}
+}
 
-	return ""
+/*
+	Writes NULL in INSERT / UPDATE / UPSERT, and becomes `col` IS NULL in WHERE.
+	INSERT / UPDATE / UPSERT 에서는 NULL 을 쓰고, WHERE 에서는 `col` IS NULL 조건이 됨.
+*/
+func DB_Null[T interface{}]() DB_Field[T] {
+	return DB_Field[T]{state: db_FIELD_NULL}
 }
 
-func DB_InitTable(tbls ...interface{}) {
+/*
+	Only valid in WHERE, becomes `col` IS NOT NULL.
+	WHERE 에서만 사용 가능, `col` IS NOT NULL 조건이 됨.
+*/
+func DB_NotNull[T interface{}]() DB_Field[T] {
+	return DB_Field[T]{state: db_FIELD_NOT_NULL}
+}
 
-	for _, t := range tbls {
+func (f *DB_Field[T]) Set(v T) {
+	f.val = v
+	f.state = db_FIELD_VALUE
+}
 
-		tbl_val := reflect.ValueOf(t).Elem()
-		tbl_type := reflect.TypeOf(t).Elem()
+func (f *DB_Field[T]) SetNull() {
+	var zero T
+	f.val = zero
+	f.state = db_FIELD_NULL
+}
 
-		for i := 0; i < tbl_val.NumField(); i++ {
-			v := tbl_val.Field(i)
-			t := tbl_type.Field(i)
-
-			switch t.Type.Kind() {
-
-			case reflect.String:
-				v.SetString(DB_UNUSE_STRING)
-
-			case reflect.Int:
-				v.SetInt(math.MaxInt)
-			case reflect.Int8:
-				v.SetInt(math.MaxInt8)
-			case reflect.Int16:
-				v.SetInt(math.MaxInt16)
-			case reflect.Int32:
-				v.SetInt(math.MaxInt32)
-			case reflect.Int64:
-				v.SetInt(math.MaxInt64)
-			case reflect.Uint:
-				v.SetUint(math.MaxUint)
-			case reflect.Uint8:
-				v.SetUint(math.MaxUint8)
-			case reflect.Uint16:
-				v.SetUint(math.MaxUint16)
-			case reflect.Uint32:
-				v.SetUint(math.MaxUint32)
-			case reflect.Uint64:
-				v.SetUint(math.MaxUint64)
-
-			case reflect.Float32, reflect.Complex64:
-				v.SetFloat(math.MaxFloat32)
-			case reflect.Float64, reflect.Complex128:
-				v.SetFloat(math.MaxFloat64)
-
-			case reflect.TypeOf(time.Time{}).Kind():
-				v.Set(reflect.ValueOf(time.Time{}))
-			}
-		}
-	}
+func (f *DB_Field[T]) SetNotNull() {
+	var zero T
+	f.val = zero
+	f.state = db_FIELD_NOT_NULL
 }
 
-func DB_NewTable[TableType interface{}](tbl TableType, count int) []TableType {
-	var ret []TableType
+func (f *DB_Field[T]) Unset() {
+	var zero T
+	f.val = zero
+	f.state = db_FIELD_UNSET
+}
 
-	for i := 0; i < count; i++ {
-		var obj TableType
-		DB_InitTable(&obj)
-		ret = append(ret, obj)
-	}
+func (f DB_Field[T]) Get() T {
+	return f.val
+}
 
-	return ret
+func (f DB_Field[T]) IsSet() bool {
+	return f.state != db_FIELD_UNSET
 }
 
-func db_Make_SELECT_Query(tbl_columns interface{}, tbl_where interface{}, raw_condition ...string) (string, error) {
+func (f DB_Field[T]) IsNull() bool {
+	return f.state == db_FIELD_NULL
+}
 
-	/*
-		Extract the columns that will be affected from the SELECT UPDATE INSERT syntax.
-		SELECT UPDATE INSERT 구문에서 영향 받을 컬럼들부터 추출.
-	*/
-	var target_column []string
+func (f DB_Field[T]) db_State() db_FieldState {
+	return f.state
+}
 
-	tbl_val := reflect.ValueOf(tbl_columns)
-	tbl_type := reflect.TypeOf(tbl_columns)
-	from_table := reflect.TypeOf(tbl_columns).Name()
+func (f DB_Field[T]) db_Value() reflect.Value {
+	return reflect.ValueOf(&f.val).Elem()
+}
 
-	for i := 0; i < tbl_val.NumField(); i++ {
-		t := tbl_type.Field(i)
+/*
+	sql.Scanner, so DB_SELECT can scan result columns directly into the field. A NULL column is kept as the NULL state.
+	The value is decoded by the codec of T.
+	DB_SELECT 가 결과 컬럼을 필드에 바로 scan 할 수 있도록 sql.Scanner 구현. NULL 컬럼은 NULL 상태로 보관.
+	값은 T 의 codec 으로 decode.
+*/
+func (f *DB_Field[T]) Scan(src interface{}) error {
+	if src == nil {
+		f.SetNull()
+		return nil
+	}
 
-		if true == db_IsUse(tbl_val.Field(i)) {
-			target_column = append(target_column, t.Name)
+	var v T
+	if codec := db_CodecOf(reflect.TypeOf(&v).Elem()); codec != nil {
+		if err := codec.Decode(reflect.ValueOf(&v).Elem(), src); err != nil {
+			return err
+		}
+	} else {
+		var n sql.Null[T]
+		if err := n.Scan(src); err != nil {
+			return err
 		}
+		v = n.V
 	}
 
-	/*
-		Extract columns and values to be conditioned in WHERE clause.
-		WHERE 절의 조건이 될 컬럼과 값을 추출.
-	*/
-	var where_str string
-	var where_column []string
-	var where_index []int
-	var where_val []reflect.Value
-
-	tbl_where_val := reflect.ValueOf(tbl_where)
-	tbl_where_type := reflect.TypeOf(tbl_where)
+	f.Set(v)
+	return nil
+}
 
-	if 0 == tbl_where_val.NumField() {
-		return "", errors.New("[ SQL ERROR ] There is no SQL WHERE column value.")
+func db_AsColumn(val reflect.Value) (db_Column, bool) {
+	if false == val.CanInterface() {
+		return nil, false
 	}
 
-	for i := 0; i < tbl_where_val.NumField(); i++ {
-		t := tbl_where_type.Field(i)
+	c, ok := val.Interface().(db_Column)
+	return c, ok
+}
 
-		val := tbl_where_val.Field(i)
-		if true == db_IsUse(val) {
-			where_index = append(where_index, i)
-			where_column = append(where_column, t.Name)
-			where_val = append(where_val, val)
-		}
+/*
+	NULL state of a column value. The NULL value of a plain type is NULL only in a Null:"true" field, elsewhere it is a plain value.
+	컬럼 값의 NULL 상태. 일반 타입의 NULL 값은 Null:"true" 필드에서만 NULL 이고, 그 외에는 일반 값.
+*/
+func db_NullState(val reflect.Value, field reflect.StructField) db_FieldState {
+	if c, ok := db_AsColumn(val); ok {
+		return c.db_State()
 	}
-	/*
-		Make Query.
-		쿼리 생성.
-	*/
-
-	var queryWhereElems []string
-	for i := 0; i < len(where_column); i++ {
-		queryWhere := ""
-		queryWhere += "`" + where_column[i] + "`"
-		queryWhere += " = "
-		queryWhere += db_ToString(where_val[i])
-		queryWhereElems = append(queryWhereElems, queryWhere)
+	if codec, ok := db_CodecOf(val.Type()).(DB_NullCodec); ok && true == db_IsNullColumn(field) && true == codec.IsNull(val) {
+		return db_FIELD_NULL
 	}
-	if 0 != len(queryWhereElems) {
-		where_str = (" WHERE " + strings.Join(queryWhereElems, " AND "))
+
+	return db_FIELD_VALUE
+}
+
+/*
+	Codec of a Go column type. Every builder and result scan goes through the codec of the column type.
+		IsUse  : whether the value is set. A column not set is left out of the query.
+		Init   : set the "not set" value. (DB_InitTable)
+		Encode : value to a query arg.
+		Decode : driver value (int64, float64, bool, []byte, string, time.Time) to the field.
+		         src is nil only for the types that can hold NULL. (pointer, sql.Scanner)
+	Go 컬럼 타입의 codec. 모든 쿼리 생성과 결과 scan 은 컬럼 타입의 codec 을 거침.
+		IsUse  : 값이 설정되었는지 여부. 설정 안된 컬럼은 쿼리에서 제외.
+		Init   : "설정 안됨" 값을 셋팅. (DB_InitTable)
+		Encode : 값을 쿼리 인자로 변환.
+		Decode : driver 값 (int64, float64, bool, []byte, string, time.Time) 을 필드로 변환.
+		         src 는 NULL 을 담을 수 있는 타입 (포인터, sql.Scanner) 에만 nil 로 전달됨.
+
+	ex)
+		DB_RegisterCodec[time.Time](DB_TimeCodec{Precision: time.Millisecond, Location: time.UTC})
+		DB_RegisterCodec[ItemGrade](itemGradeCodec{})
+*/
+type DB_Codec interface {
+	IsUse(val reflect.Value) bool
+	Init(val reflect.Value)
+	Encode(val reflect.Value) (interface{}, error)
+	Decode(val reflect.Value, src interface{}) error
+}
+
+/*
+	Codec of a plain type which has a NULL value next to its "not set" value. (string DB_NULL_STRING, integer min value ...)
+	A Null:"true" plain field reads NULL as that value, writes it as NULL, and it becomes `col` IS NULL in WHERE.
+	The NULL value is written as NULL without Encode. In a field without the tag it is a plain value, and Encode encodes it.
+	"설정 안됨" 값과 별도로 NULL 값을 가지는 일반 타입의 codec. (string DB_NULL_STRING, 정수 최소값 ...)
+	Null:"true" 일반 필드는 NULL 을 그 값으로 읽고, 그 값을 NULL 로 쓰며, WHERE 에서는 `col` IS NULL 조건이 됨.
+	NULL 값은 Encode 없이 NULL 로 기록됨. 태그가 없는 필드에서는 일반 값이며, Encode 가 변환함.
+*/
+type DB_NullCodec interface {
+	SetNull(val reflect.Value)
+	IsNull(val reflect.Value) bool
+}
+
+/*
+	Set the NULL value to a Null:"true" plain field, or a DB_Field.
+	Null:"true" 일반 필드나 DB_Field 에 NULL 값을 셋팅.
+
+	ex)
+		DB_SetNull(&tbl_target.PlatformIdx)
+		DB_UPDATE(db, tbl_target, tbl_where)	<- UPDATE `tblaccount` SET `PlatformIdx`=? ... ([nil])
+*/
+func DB_SetNull(field interface{}) error {
+	val := reflect.ValueOf(field)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New(fmt.Sprint("[ SQL ERROR ] DB_SetNull needs a pointer to the field - ", val.Type()))
 	}
 
-	if 0 < len(raw_condition) {
-		where_str += (" " + raw_condition[0])
+	if f, ok := field.(interface{ SetNull() }); ok {
+		f.SetNull()
+		return nil
 	}
 
-	queryStr := "SELECT `" + strings.Join(target_column, "`, `") + "` FROM " + from_table + where_str + ";"
-	logger.Info(queryStr)
+	codec, ok := db_CodecOf(val.Type().Elem()).(DB_NullCodec)
+	if false == ok {
+		return errors.New(fmt.Sprint("[ SQL ERROR ] There is no NULL value for ", val.Type().Elem()))
+	}
 
-	return queryStr, nil
+	codec.SetNull(val.Elem())
+	return nil
 }
 
-func db_Make_INSERT_Query[DB_Table interface{}](tbl_insert ...DB_Table) (string, error) {
+func db_IsNullColumn(field reflect.StructField) bool {
+	return "true" == field.Tag.Get("Null")
+}
 
-	if 1 > len(tbl_insert) {
-		return "", errors.New(fmt.Sprint("[ SQL ERROR ] There is no data for INSERT"))
+var (
+	db_codecMutex sync.RWMutex
+	db_codecs     = map[reflect.Type]DB_Codec{
+		reflect.TypeOf(time.Time{}): DB_TimeCodec{Precision: time.Second},
+		reflect.TypeOf([]byte{}):    db_BytesCodec{},
 	}
 
-	queryStr := "INSERT INTO "
-
-	/*
-		This function optionally allows you to attempt to INSERT only a few columns.
-		이 함수에서는 선택적으로 특정 몇몇 컬럼만을 INSERT 하려고 하는 행위를 허용함.
-	*/
-	var valid_field_index []int
+	db_columnType  = reflect.TypeOf((*db_Column)(nil)).Elem()
+	db_valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	db_scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
 
-	{
-		/*
-			Extract the table name and each column name.
-			테이블 명과 각 컬럼명을 추출.
-		*/
-		tbl := reflect.ValueOf(&tbl_insert[0]).Elem()
-		field_size := tbl.NumField()
-		tbl_type := reflect.TypeOf(&tbl_insert[0]).Elem()
+/*
+	Register the codec of T, it replaces the built-in codec of T.
+	Register on server start, before any query.
+	T 의 codec 을 등록, T 의 기본 codec 을 대체함.
+	서버 시작 시, 쿼리 전에 등록.
+*/
+func DB_RegisterCodec[T interface{}](codec DB_Codec) {
+	db_codecMutex.Lock()
+	defer db_codecMutex.Unlock()
 
-		var field_names []string
-		for i := 0; i < field_size; i++ {
-			t := tbl_type.Field(i)
+	db_codecs[reflect.TypeOf((*T)(nil)).Elem()] = codec
+}
 
-			if true == db_IsUse(tbl.Field(i)) {
-				field_names = append(field_names, t.Name)
-				valid_field_index = append(valid_field_index, i)
-			}
-		}
+/*
+	Registered codec first, then DB_Field, pointer, driver.Valuer / sql.Scanner types, then the kind of the type.
+	So a named type (ex. type Gold int64) uses the codec of its kind unless it is registered.
+	nil means the type is not a column value.
+	등록된 codec 을 먼저, 다음으로 DB_Field, 포인터, driver.Valuer / sql.Scanner 타입, 마지막으로 타입의 kind.
+	따라서 이름 있는 타입 (ex. type Gold int64) 은 등록하지 않으면 kind 의 codec 을 사용.
+	nil 은 컬럼 값이 아닌 타입.
+*/
+func db_CodecOf(t reflect.Type) DB_Codec {
+	db_codecMutex.RLock()
+	codec, ok := db_codecs[t]
+	db_codecMutex.RUnlock()
+	if true == ok {
+		return codec
+	}
 
-		/*
-			Change extracted name to query string.
-			추출한 명칭을 쿼리 문자열로 변경.
-		*/
-		queryStr += (tbl_type.Name() + " (`")
-		queryStr += (strings.Join(field_names, "`, `") + "`) VALUES ")
+	switch {
+	case t.Implements(db_columnType):
+		return db_FieldCodec{}
+	case t.Kind() == reflect.Ptr:
+		return db_PtrCodec{}
+	case t.Implements(db_valuerType) || db_IsScannerType(t):
+		return db_ValuerCodec{}
 	}
-	{
-		/*
-			Change each value to be INSERT query form.
-			INSERT 할 각 값들을 쿼리 형태로 변경.
-		*/
-		var tbl_elem_array []string
-		for _, tbl_elem := range tbl_insert {
-			tbl := reflect.ValueOf(&tbl_elem).Elem()
-			field_size := tbl.NumField()
 
-			// elem_row_value == arr[ field1_value, field2_value, ... ]
-			var elem_row_value []string
-			for i := 0; i < field_size; i++ {
-				val := tbl.Field(i)
+	switch t.Kind() {
+	case reflect.String:
+		return db_StringCodec{}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return db_IntCodec{}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return db_UintCodec{}
+	case reflect.Float32, reflect.Float64:
+		return db_FloatCodec{}
+	case reflect.Bool:
+		return db_BoolCodec{}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return db_BytesCodec{}
+		}
+	}
 
-				if true == db_IsUse(val) {
-					elem_row_value = append(elem_row_value, db_ToString(val))
-				}
-			}
+	return nil
+}
 
-			// elem_row_value make row like strings => (field1_value, field2_value, ...)
-			tbl_elem_array = append(tbl_elem_array, "("+strings.Join(elem_row_value, ", ")+")")
+/*
+	Columns whose value can not be told set or not are an error, instead of being left out silently. (plain bool)
+	설정 여부를 알 수 없는 컬럼은 조용히 제외하지 않고 에러. (일반 bool)
+*/
+func db_CheckColumns(tbl_type reflect.Type) error {
+	for _, col := range db_Columns(tbl_type) {
+		if _, ok := db_CodecOf(col.Field.Type).(db_BoolCodec); ok {
+			logger.Error("[ SQL ERROR ] Plain bool column, use DB_Field[bool] or *bool - ", db_TableName(tbl_type), ".", col.Name)
+			return errors.New(fmt.Sprint("[ SQL ERROR ] Plain bool column, use DB_Field[bool] or *bool - ", db_TableName(tbl_type), ".", col.Name))
 		}
-
-		// queryStr's final query form => INSERT INTO tbl (col1, col2, ...) VALUES (val1, val2, ...), ... ;
-		queryStr += (strings.Join(tbl_elem_array, ", ") + ";")
-		logger.Info(queryStr)
 	}
 
-	return queryStr, nil
+	return nil
 }
 
-func db_Make_UPDATE_Query(tbl_columns interface{}, tbl_where interface{}, raw_condition ...string) (string, error) {
+func db_IsScannerType(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(db_scannerType)
+}
 
-	from_table := reflect.TypeOf(tbl_columns).Name()
-	queryStr := "UPDATE " + from_table + " SET "
+func db_IsUse(val reflect.Value) bool {
+	codec := db_CodecOf(val.Type())
+	return codec != nil && codec.IsUse(val)
+}
 
-	{
-		var set_field_cmd []string
-		tbl_val := reflect.ValueOf(tbl_columns)
-		tbl_type := reflect.TypeOf(tbl_columns)
-		for i := 0; i < tbl_val.NumField(); i++ {
-			reflect_v := tbl_val.Field(i)
-			reflect_t := tbl_type.Field(i).Name
-			if true == db_IsUse(reflect_v) {
-				set_field_cmd = append(set_field_cmd, "`"+reflect_t+"`="+db_ToString(reflect_v))
-			}
-		}
+func db_ToArg(val reflect.Value) interface{} {
+	codec := db_CodecOf(val.Type())
+	if codec == nil {
+		return nil
+	}
 
-		queryStr += strings.Join(set_field_cmd, ", ")
+	arg, err := codec.Encode(val)
+	if err != nil {
+		logger.Error(err)
+		return nil
 	}
 
-	{
-		var where_str string
-		var set_field_cmd []string
-		tbl_val := reflect.ValueOf(tbl_where)
-		tbl_type := reflect.TypeOf(tbl_where)
-		for i := 0; i < tbl_val.NumField(); i++ {
-			reflect_v := tbl_val.Field(i)
-			reflect_t := tbl_type.Field(i).Name
-			if true == db_IsUse(reflect_v) {
-				set_field_cmd = append(set_field_cmd, "`"+reflect_t+"`="+db_ToString(reflect_v))
-			}
-		}
+	return arg
+}
 
-		if 0 != len(set_field_cmd) {
-			where_str = " WHERE " + strings.Join(set_field_cmd, " AND ")
-		}
+func db_ToWriteArg(val reflect.Value, field reflect.StructField) (interface{}, error) {
+	switch db_NullState(val, field) {
+	case db_FIELD_NOT_NULL:
+		return nil, errors.New("[ SQL ERROR ] IS NOT NULL can only be used in WHERE")
+	case db_FIELD_NULL:
+		return nil, nil
+	}
+
+	return db_ToArg(val), nil
+}
+
+/*
+	Scan destination of a result column, decoded by the codec of the field type.
+	NULL into a plain field is an error, unless keep_null is set. (Null:"true" tag, aggregates, LEFT JOIN)
+	A Null:"true" field gets the NULL value of its DB_NullCodec, so writing it back writes NULL.
+	Otherwise the field keeps its DB_InitTable "not set" value, so writing it back leaves the column out.
+	필드 타입의 codec 으로 decode 하는 결과 컬럼의 scan 대상.
+	keep_null 이 아니면 일반 필드로의 NULL 은 에러. (Null:"true" 태그, 집계, LEFT JOIN)
+	Null:"true" 필드는 DB_NullCodec 의 NULL 값을 가지므로, 다시 쓰면 NULL 이 기록됨.
+	그 외에는 DB_InitTable 의 "설정 안됨" 값을 유지하므로, 다시 쓸 때 해당 컬럼은 제외됨.
+*/
+type db_ScanField struct {
+	field     reflect.Value
+	codec     DB_Codec
+	name      string
+	keep_null bool
+	set_null  bool
+}
 
-		if 0 < len(raw_condition) {
-			where_str += (" " + raw_condition[0])
+func (s db_ScanField) Scan(src interface{}) error {
+	if src == nil && s.field.Kind() != reflect.Ptr && false == db_IsScannerType(s.field.Type()) {
+		if null_codec, ok := s.codec.(DB_NullCodec); ok && true == s.set_null {
+			null_codec.SetNull(s.field)
+			return nil
+		}
+		if true == s.keep_null {
+			return nil
 		}
+		return errors.New(fmt.Sprint("[ SQL ERROR ] NULL value for a column without Null tag - ", s.name))
+	}
 
-		queryStr += (where_str + ";")
+	if s.codec == nil {
+		return errors.New(fmt.Sprint("[ SQL ERROR ] There is no codec for ", s.field.Type(), " - ", s.name))
 	}
 
-	logger.Info(queryStr)
-	return queryStr, nil
+	return s.codec.Decode(s.field, src)
 }
 
-func db_Make_DELETE_Query(tbl_where interface{}, raw_condition ...string) (string, error) {
-	from_table := reflect.TypeOf(tbl_where).Name()
-	queryStr := "DELETE FROM " + from_table
+func db_ScanDest(field reflect.Value, field_type reflect.StructField) interface{} {
+	isNullAllow := db_IsNullColumn(field_type)
+	return db_ScanField{field: field, codec: db_CodecOf(field.Type()), name: field_type.Name, keep_null: isNullAllow, set_null: isNullAllow}
+}
 
-	{
-		var where_str string
-		var set_field_cmd []string
-		tbl_val := reflect.ValueOf(tbl_where)
-		tbl_type := reflect.TypeOf(tbl_where)
-		for i := 0; i < tbl_val.NumField(); i++ {
-			reflect_v := tbl_val.Field(i)
-			reflect_t := tbl_type.Field(i).Name
-			if true == db_IsUse(reflect_v) {
-				set_field_cmd = append(set_field_cmd, "`"+reflect_t+"`="+db_ToString(reflect_v))
-			}
-		}
+/*
+	Scan destination that keeps the field value as it is when NULL is read. (ex. SUM of no rows)
+	NULL 을 읽으면 필드 값을 그대로 두는 Scan 대상. (ex. row 가 없는 SUM)
+*/
+func db_ScanNullableDest(field reflect.Value) interface{} {
+	return db_ScanField{field: field, codec: db_CodecOf(field.Type()), keep_null: true}
+}
 
-		if 0 != len(set_field_cmd) {
-			where_str = " WHERE " + strings.Join(set_field_cmd, " AND ")
-		}
+func db_DecodeError(src interface{}, val reflect.Value) error {
+	return errors.New(fmt.Sprint("[ SQL ERROR ] Can not decode ", reflect.TypeOf(src), " into ", val.Type()))
+}
 
-		if 0 < len(raw_condition) {
-			where_str += (" " + raw_condition[0])
-		}
+/*
+	DB_Field tracks "not set" by itself, so it is only reset to the zero value. NULL / IS NOT NULL are encoded as nil.
+	DB_Field 는 스스로 "설정 안됨" 을 관리하므로, zero value 로만 초기화. NULL / IS NOT NULL 은 nil 로 변환.
+*/
+type db_FieldCodec struct{}
 
-		queryStr += (where_str + ";")
+func (db_FieldCodec) IsUse(val reflect.Value) bool {
+	return val.Interface().(db_Column).db_State() != db_FIELD_UNSET
+}
+
+func (db_FieldCodec) Init(val reflect.Value) { val.Set(reflect.Zero(val.Type())) }
+
+func (db_FieldCodec) Encode(val reflect.Value) (interface{}, error) {
+	c := val.Interface().(db_Column)
+	if c.db_State() != db_FIELD_VALUE {
+		return nil, nil
 	}
 
-	logger.Info(queryStr)
-	return queryStr, nil
+	inner := c.db_Value()
+	codec := db_CodecOf(inner.Type())
+	if codec == nil {
+		return inner.Interface(), nil
+	}
+	return codec.Encode(inner)
 }
 
-func db_Make_UPSERT_Query[DB_Table interface{}](tbl_insert DB_Table) (string, error) {
+func (db_FieldCodec) Decode(val reflect.Value, src interface{}) error {
+	return val.Addr().Interface().(sql.Scanner).Scan(src)
+}
 
-	queryStr := "INSERT INTO "
+/*
+	Pointer columns are "not set" when nil, the pointed value uses its own codec.
+	포인터 컬럼은 nil 일 때 "설정 안됨", 가리키는 값은 자신의 codec 을 사용.
+*/
+type db_PtrCodec struct{}
 
-	/*
-		This function optionally allows you to attempt to INSERT only a few columns.
-		이 함수에서는 선택적으로 특정 몇몇 컬럼만을 INSERT 하려고 하는 행위를 허용함.
-	*/
-	var valid_field_index []int
+func (db_PtrCodec) IsUse(val reflect.Value) bool { return false == val.IsNil() }
 
-	//var not_pk_col_index []int
-	not_pk_col_value := make(map[string]string, 0)
-	var field_names []string
-	var elem_row_value []string
-	{
-		/*
-			Extract the table name and each column name.
-			테이블 명과 각 컬럼명을 추출.
-		*/
-		tbl := reflect.ValueOf(&tbl_insert).Elem()
-		field_size := tbl.NumField()
-		tbl_type := reflect.TypeOf(&tbl_insert).Elem()
+func (db_PtrCodec) Init(val reflect.Value) { val.Set(reflect.Zero(val.Type())) }
 
-		for i := 0; i < field_size; i++ {
-			t := tbl_type.Field(i)
+func (db_PtrCodec) Encode(val reflect.Value) (interface{}, error) {
+	if val.IsNil() {
+		return nil, nil
+	}
 
-			if true == db_IsUse(tbl.Field(i)) {
-				field_names = append(field_names, t.Name)
-				valid_field_index = append(valid_field_index, i)
-				_, thisIsPK := t.Tag.Lookup("PK")
-				if false == thisIsPK {
-					not_pk_col_value[t.Name] = ""
-					//not_pk_col_index = append(not_pk_col_index, i)
-				}
-			}
-		}
+	codec := db_CodecOf(val.Type().Elem())
+	if codec == nil {
+		return val.Elem().Interface(), nil
+	}
+	return codec.Encode(val.Elem())
+}
 
-		/*
-			Change extracted name to query string.
-			추출한 명칭을 쿼리 문자열로 변경.
-		*/
-		queryStr += (tbl_type.Name() + " (`")
-		queryStr += (strings.Join(field_names, "`, `") + "`) VALUES ")
+func (db_PtrCodec) Decode(val reflect.Value, src interface{}) error {
+	if src == nil {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
 	}
-	{
-		/*
-			Change each value to be INSERT query form.
-			INSERT 할 각 값들을 쿼리 형태로 변경.
-		*/
-		var tbl_elem_array []string
-		tbl := reflect.ValueOf(&tbl_insert).Elem()
-		tbl_type := reflect.TypeOf(&tbl_insert).Elem()
-		field_size := tbl.NumField()
-
-		// elem_row_value == arr[ field1_value, field2_value, ... ]
-		for i := 0; i < field_size; i++ {
-			t := tbl_type.Field(i)
-			val := tbl.Field(i)
-
-			if true == db_IsUse(val) {
-				elem_row_value = append(elem_row_value, db_ToString(val))
-				if _, ok := not_pk_col_value[t.Name]; ok {
-					not_pk_col_value[t.Name] = db_ToString(val)
-				}
-			}
-		}
 
-		// elem_row_value make row like strings => (field1_value, field2_value, ...)
-		tbl_elem_array = append(tbl_elem_array, "("+strings.Join(elem_row_value, ", ")+")")
+	codec := db_CodecOf(val.Type().Elem())
+	if codec == nil {
+		return db_DecodeError(src, val)
+	}
 
-		// queryStr's final query form => INSERT INTO tbl (col1, col2, ...) VALUES (val1, val2, ...) ON DUPLICATE KEY UPDATE
-		queryStr += (strings.Join(tbl_elem_array, ", ") + " ON DUPLICATE KEY UPDATE ")
+	elem := reflect.New(val.Type().Elem())
+	if err := codec.Decode(elem.Elem(), src); err != nil {
+		return err
 	}
-	{
-		/*
-			UPDATE 구문 추가
-		*/
-		var name_val_set_query_elems []string
-		for key, val := range not_pk_col_value {
-			name_val_set_query_elems = append(name_val_set_query_elems, "`"+key+"`="+val)
-		}
+	val.Set(elem)
+	return nil
+}
 
-		queryStr += (strings.Join(name_val_set_query_elems, ", ") + "; ")
-		logger.Info(queryStr)
+/*
+	sql.NullString, sql.NullInt64 ... and other driver.Valuer / sql.Scanner types.
+	A Valuer returning nil (Valid == false) is treated as not set, a Scanner without Valuer is not set when zero.
+	sql.NullString, sql.NullInt64 ... 등의 driver.Valuer / sql.Scanner 타입.
+	nil 을 반환하는 (Valid == false) Valuer 는 설정 안됨으로, Valuer 가 없는 Scanner 는 zero value 일 때 설정 안됨으로 취급.
+*/
+type db_ValuerCodec struct{}
+
+func (db_ValuerCodec) IsUse(val reflect.Value) bool {
+	if valuer, ok := val.Interface().(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		return err == nil && dv != nil
 	}
 
-	return queryStr, nil
+	return false == val.IsZero()
 }
 
-func db_Make_INCR_Query(tbl_columns interface{}, tbl_where interface{}, size int64, raw_condition ...string) (string, error) {
+func (db_ValuerCodec) Init(val reflect.Value) { val.Set(reflect.Zero(val.Type())) }
 
-	from_table := reflect.TypeOf(tbl_columns).Name()
-	queryStr := "UPDATE " + from_table + " SET "
+func (db_ValuerCodec) Encode(val reflect.Value) (interface{}, error) {
+	if valuer, ok := val.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
 
-	{
-		var set_field_cmd []string
-		tbl_val := reflect.ValueOf(tbl_columns)
-		tbl_type := reflect.TypeOf(tbl_columns)
-		for i := 0; i < tbl_val.NumField(); i++ {
-			reflect_v := tbl_val.Field(i)
-			reflect_t := tbl_type.Field(i).Name
-			if true == db_IsUse(reflect_v) {
-				var operator string
-				var s int64
-				if 0 <= size {
-					operator = "+"
-					s = size
-				} else {
-					operator = "-"
-					s = size * -1
-				}
-				set_field_cmd = append(set_field_cmd, "`"+reflect_t+"`="+reflect_t+operator+strconv.FormatInt(s, 10))
-			}
-		}
+	return val.Interface(), nil
+}
 
-		queryStr += strings.Join(set_field_cmd, ", ")
+func (db_ValuerCodec) Decode(val reflect.Value, src interface{}) error {
+	scanner, ok := val.Addr().Interface().(sql.Scanner)
+	if false == ok {
+		return db_DecodeError(src, val)
 	}
 
-	{
-		var where_str string
-		var set_field_cmd []string
-		tbl_val := reflect.ValueOf(tbl_where)
-		tbl_type := reflect.TypeOf(tbl_where)
-		for i := 0; i < tbl_val.NumField(); i++ {
-			reflect_v := tbl_val.Field(i)
-			reflect_t := tbl_type.Field(i).Name
-			if true == db_IsUse(reflect_v) {
-				set_field_cmd = append(set_field_cmd, "`"+reflect_t+"`="+db_ToString(reflect_v))
-			}
-		}
+	return scanner.Scan(src)
+}
 
-		if 0 != len(set_field_cmd) {
-			where_str = " WHERE " + strings.Join(set_field_cmd, " AND ")
-		}
+/*
+	String columns are "not set" with DB_UNUSE_STRING, and NULL with DB_NULL_STRING.
+	문자열 컬럼은 DB_UNUSE_STRING 일 때 "설정 안됨", DB_NULL_STRING 일 때 NULL.
+*/
+type db_StringCodec struct{}
 
-		if 0 < len(raw_condition) {
-			where_str += (" " + raw_condition[0])
-		}
+func (db_StringCodec) IsUse(val reflect.Value) bool { return val.String() != DB_UNUSE_STRING }
 
-		queryStr += (where_str + ";")
+func (db_StringCodec) Init(val reflect.Value) { val.SetString(DB_UNUSE_STRING) }
+
+func (db_StringCodec) SetNull(val reflect.Value) { val.SetString(DB_NULL_STRING) }
+
+func (db_StringCodec) IsNull(val reflect.Value) bool { return val.String() == DB_NULL_STRING }
+
+func (c db_StringCodec) Encode(val reflect.Value) (interface{}, error) {
+	return val.String(), nil
+}
+
+func (db_StringCodec) Decode(val reflect.Value, src interface{}) error {
+	switch s := src.(type) {
+	case string:
+		val.SetString(s)
+	case []byte:
+		val.SetString(string(s))
+	case time.Time:
+		val.SetString(s.Format("2006-01-02 15:04:05"))
+	default:
+		val.SetString(fmt.Sprint(s))
 	}
 
-	logger.Info(queryStr)
-	return queryStr, nil
+	return nil
 }
 
 /*
-	< How To Use >
-	ex)
-		type tblaccount struct {
-			PlayerKey   string
-			UserUUID    int64
-			ConnectIP   string
-			ConnectTime time.Time
-			CreateTime  time.Time
-			GameDBID    int
-		}
+	Integer columns are "not set" with the max value of their size, and NULL with the min value. (math.MaxInt8 ... math.MaxInt64)
+	정수 컬럼은 크기별 최대값일 때 "설정 안됨", 최소값일 때 NULL. (math.MaxInt8 ... math.MaxInt64)
+*/
+type db_IntCodec struct{}
 
-		var tbl_select, tbl_where tblaccount
-		DB_InitTable(&tbl_select, &tbl_where)
+func (db_IntCodec) unuse(val reflect.Value) int64 { return math.MaxInt64 >> (64 - val.Type().Bits()) }
 
-		tbl_select.PlayerKey = "Yea"			<- It doesn't matter what value you set
-		tbl_select.GameDBID = 9999				<- It doesn't matter what value you set
+func (db_IntCodec) null(val reflect.Value) int64 { return math.MinInt64 >> (64 - val.Type().Bits()) }
 
-		tbl_where.ConnectIP = "127.0.0.1"		<- Setting value is important in setting query conditions
-		tbl_where.GameDBID = 1					<- Setting value is important in setting query conditions
-		tbl_where.UserUUID = 10					<- Setting value is important in setting query conditions
+func (c db_IntCodec) IsUse(val reflect.Value) bool { return val.Int() != c.unuse(val) }
 
-		DB_SELECT(db, &tbl_select, &tbl_where)	<- Sended Query : SELECT PlayerKey, GameDBID FROM tblaccount WHERE UserUUID = 10 AND ConnectIP = "127.0.0.1" AND GameDBID = 1
+func (c db_IntCodec) Init(val reflect.Value) { val.SetInt(c.unuse(val)) }
 
-		DB_SELECT(db, tbl_select, tblaccount{}, "ORDER BY UserUUID ASC Limit 10") <- Sended Query : SELECT PlayerKey, GameDBID FROM tblaccount ORDER BY UserUUID ASC Limit 10
-*/
-func DB_SELECT[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, raw_condition ...string) ([]DB_Table, error) {
+func (c db_IntCodec) SetNull(val reflect.Value) { val.SetInt(c.null(val)) }
 
-	logger := InitLogger()
-	var retValues []DB_Table
+func (c db_IntCodec) IsNull(val reflect.Value) bool { return val.Int() == c.null(val) }
 
-	/*
-		Check that each table type is the same.
-		각 테이블 타입이 동일한지 체크.
-	*/
-	{
-		tb_col_t := reflect.TypeOf(&tbl_target)
-		tb_where_t := reflect.TypeOf(&tbl_where)
+func (c db_IntCodec) Encode(val reflect.Value) (interface{}, error) {
+	return val.Int(), nil
+}
 
-		if tb_col_t.Name() != tb_where_t.Name() {
-			logger.Error(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
-			return retValues, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
-		}
-	}
+func (db_IntCodec) Decode(val reflect.Value, src interface{}) error {
+	var n int64
+	var err error
 
-	/*
-		Save the source member variable index value to receive the value.
-		값을 받을 원본 멤버 변수 인덱스 값을 저장.
-	*/
-	var target_index []int
-	tbl_val := reflect.ValueOf(&tbl_target).Elem()
-	for i := 0; i < tbl_val.NumField(); i++ {
-		if true == db_IsUse(tbl_val.Field(i)) {
-			target_index = append(target_index, i)
+	switch s := src.(type) {
+	case int64:
+		n = s
+	case uint64:
+		if s > math.MaxInt64 {
+			return db_DecodeError(src, val)
+		}
+		n = int64(s)
+	case float64:
+		n = int64(s)
+		if float64(n) != s {
+			return db_DecodeError(src, val)
+		}
+	case bool:
+		if true == s {
+			n = 1
 		}
+	case []byte:
+		n, err = strconv.ParseInt(string(s), 10, 64)
+	case string:
+		n, err = strconv.ParseInt(s, 10, 64)
+	default:
+		return db_DecodeError(src, val)
 	}
 
-	queryStr, err := db_Make_SELECT_Query(tbl_target, tbl_where, raw_condition...)
-	if err != nil {
-		return retValues, err
+	if err != nil || val.OverflowInt(n) {
+		return db_DecodeError(src, val)
 	}
 
-	rows, err := db.Query(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
-		return retValues, err
+	val.SetInt(n)
+	return nil
+}
+
+/*
+	Unsigned columns are "not set" with the max value of their size, and NULL with the max value - 1.
+	database/sql does not take uint64 args with the high bit set, so those are written as a decimal string.
+	부호 없는 정수 컬럼은 크기별 최대값일 때 "설정 안됨", 최대값 - 1 일 때 NULL.
+	database/sql 은 최상위 비트가 켜진 uint64 인자를 받지 않으므로, 그 값은 10진수 문자열로 전달.
+*/
+type db_UintCodec struct{}
+
+func (db_UintCodec) unuse(val reflect.Value) uint64 {
+	return math.MaxUint64 >> (64 - val.Type().Bits())
+}
+
+func (c db_UintCodec) IsUse(val reflect.Value) bool { return val.Uint() != c.unuse(val) }
+
+func (c db_UintCodec) Init(val reflect.Value) { val.SetUint(c.unuse(val)) }
+
+func (c db_UintCodec) SetNull(val reflect.Value) { val.SetUint(c.unuse(val) - 1) }
+
+func (c db_UintCodec) IsNull(val reflect.Value) bool { return val.Uint() == c.unuse(val)-1 }
+
+func (c db_UintCodec) Encode(val reflect.Value) (interface{}, error) {
+	n := val.Uint()
+	if n > math.MaxInt64 {
+		return strconv.FormatUint(n, 10), nil
 	}
 
-	for rows.Next() {
+	return int64(n), nil
+}
 
-		/*
-			Set table member var address parameters to receive results.
-			결과 받을 테이블 주소 파라미터 셋팅.
-		*/
-		var obj DB_Table
-		DB_InitTable(&obj)
-		retT_val := reflect.ValueOf(&obj).Elem()
-		var target_ptr_list []interface{}
-		for _, d := range target_index {
-			target_ptr_list = append(target_ptr_list, retT_val.Field(d).Addr().Interface())
-		}
+func (db_UintCodec) Decode(val reflect.Value, src interface{}) error {
+	var n uint64
+	var err error
 
-		/*
-			Create result values sequentially on table objects.
-			테이블 객체에 순차적으로 결과 값 작성.
-		*/
-		err = rows.Scan(target_ptr_list...)
-		if err != nil {
-			logger.Error(err)
-			return retValues, err
+	switch s := src.(type) {
+	case int64:
+		if s < 0 {
+			return db_DecodeError(src, val)
+		}
+		n = uint64(s)
+	case uint64:
+		n = s
+	case float64:
+		n = uint64(s)
+		if s < 0 || float64(n) != s {
+			return db_DecodeError(src, val)
 		}
+	case bool:
+		if true == s {
+			n = 1
+		}
+	case []byte:
+		n, err = strconv.ParseUint(string(s), 10, 64)
+	case string:
+		n, err = strconv.ParseUint(s, 10, 64)
+	default:
+		return db_DecodeError(src, val)
+	}
 
-		retValues = append(retValues, obj)
+	if err != nil || val.OverflowUint(n) {
+		return db_DecodeError(src, val)
 	}
 
-	return retValues, nil
+	val.SetUint(n)
+	return nil
 }
 
-func isValid_insert[DB_Table interface{}](tbl_insert ...DB_Table) (bool, error) {
+/*
+	Float columns are "not set" with the max value of their size, and NULL with -max.
+	실수 컬럼은 크기별 최대값일 때 "설정 안됨", -최대값 일 때 NULL.
+*/
+type db_FloatCodec struct{}
 
-	if 1 > len(tbl_insert) {
-		logger.Error("[ SQL ERROR ] There is no data for INSERT")
-		return false, errors.New("[ SQL ERROR ] There is no data for INSERT")
+func (db_FloatCodec) unuse(val reflect.Value) float64 {
+	if val.Kind() == reflect.Float32 {
+		return math.MaxFloat32
 	}
+	return math.MaxFloat64
+}
 
-	/*
-		Error handling if any of the table column values to be INSERT are abnormal.
-		INSERT 할 테이블 컬럼 값이 하나라도 비정상인 경우 에러처리.
-	*/
-	tbl_name := reflect.TypeOf(tbl_insert[0]).Name()
+func (c db_FloatCodec) IsUse(val reflect.Value) bool { return val.Float() != c.unuse(val) }
 
-	for _, tbl_in := range tbl_insert {
-		tbl_val := reflect.ValueOf(&tbl_in).Elem()
+func (c db_FloatCodec) Init(val reflect.Value) { val.SetFloat(c.unuse(val)) }
 
-		elemTbl_name := reflect.TypeOf(tbl_in).Name()
-		if tbl_name != elemTbl_name {
-			logger.Error("[ SQL ERROR ] Not Same tables elements in INSERT ( ", tbl_name, " <> ", elemTbl_name, " )")
-			return false, errors.New(fmt.Sprint("[ SQL ERROR ] Not Same tables elements in INSERT ( ", tbl_name, " <> ", elemTbl_name, " )"))
-		}
+func (c db_FloatCodec) SetNull(val reflect.Value) { val.SetFloat(-c.unuse(val)) }
 
-		tbl_type := reflect.TypeOf(&tbl_in).Elem()
-		for i := 0; i < tbl_val.NumField(); i++ {
-			t := tbl_type.Field(i)
-			_, isNullAllow := t.Tag.Lookup("Null")
-			if true != isNullAllow {
-				if true != db_IsUse(tbl_val.Field(i)) {
-					logger.Errorf("[ SQL ERROR ] Invalid table field value - %v", elemTbl_name)
-					return false, errors.New(fmt.Sprint("[ SQL ERROR ] Invalid table field value - ", elemTbl_name))
-				}
-			}
-		}
+func (c db_FloatCodec) IsNull(val reflect.Value) bool { return val.Float() == -c.unuse(val) }
+
+func (c db_FloatCodec) Encode(val reflect.Value) (interface{}, error) {
+	return val.Float(), nil
+}
+
+func (db_FloatCodec) Decode(val reflect.Value, src interface{}) error {
+	var f float64
+	var err error
+
+	switch s := src.(type) {
+	case float64:
+		f = s
+	case int64:
+		f = float64(s)
+	case uint64:
+		f = float64(s)
+	case []byte:
+		f, err = strconv.ParseFloat(string(s), val.Type().Bits())
+	case string:
+		f, err = strconv.ParseFloat(s, val.Type().Bits())
+	default:
+		return db_DecodeError(src, val)
 	}
 
-	return true, nil
+	if err != nil || val.OverflowFloat(f) {
+		return db_DecodeError(src, val)
+	}
+
+	val.SetFloat(f)
+	return nil
 }
 
-func DB_INSERT[DB_Table interface{}](db *sql.DB, tbl_insert ...DB_Table) (int64, error) {
+/*
+	A plain bool has no "not set" value, so a table with a plain bool column is an error in every query. Use DB_Field[bool] or *bool.
+	The codec is kept for the value of DB_Field[bool].
+	일반 bool 은 "설정 안됨" 값이 없으므로, 일반 bool 컬럼이 있는 테이블은 모든 쿼리에서 에러. DB_Field[bool] 이나 *bool 을 사용.
+	이 codec 은 DB_Field[bool] 의 값을 위해 남겨둠.
+*/
+type db_BoolCodec struct{}
+
+func (db_BoolCodec) IsUse(val reflect.Value) bool { return false }
 
-	_, err := isValid_insert(tbl_insert...)
-	if err != nil {
-		return 0, err
+func (db_BoolCodec) Init(val reflect.Value) { val.SetBool(false) }
+
+func (db_BoolCodec) Encode(val reflect.Value) (interface{}, error) { return val.Bool(), nil }
+
+func (db_BoolCodec) Decode(val reflect.Value, src interface{}) error {
+	var b bool
+	var err error
+
+	switch s := src.(type) {
+	case bool:
+		b = s
+	case int64:
+		b = s != 0
+	case []byte:
+		b, err = strconv.ParseBool(string(s))
+	case string:
+		b, err = strconv.ParseBool(s)
+	default:
+		return db_DecodeError(src, val)
 	}
 
-	queryStr, err := db_Make_INSERT_Query(tbl_insert...)
 	if err != nil {
-		return 0, err
+		return db_DecodeError(src, val)
 	}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Exec error - ", err)
-		return 0, err
+	val.SetBool(b)
+	return nil
+}
+
+/*
+	[]byte columns are "not set" when nil, an empty non nil slice is written as empty bytes.
+	[]byte 컬럼은 nil 일 때 "설정 안됨", nil 이 아닌 빈 slice 는 빈 바이트로 기록.
+*/
+type db_BytesCodec struct{}
+
+func (db_BytesCodec) IsUse(val reflect.Value) bool { return false == val.IsNil() }
+
+func (db_BytesCodec) Init(val reflect.Value) { val.Set(reflect.Zero(val.Type())) }
+
+func (db_BytesCodec) Encode(val reflect.Value) (interface{}, error) {
+	if val.IsNil() {
+		return nil, nil
 	}
 
-	affect, err := res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - ", err)
+	return val.Bytes(), nil
+}
+
+func (db_BytesCodec) Decode(val reflect.Value, src interface{}) error {
+	var b []byte
+
+	switch s := src.(type) {
+	case []byte:
+		b = append([]byte{}, s...)
+	case string:
+		b = []byte(s)
+	default:
+		return db_DecodeError(src, val)
 	}
 
-	return affect, err
+	val.SetBytes(b)
+	return nil
+}
+
+/*
+	time.Time codec, "not set" when zero, NULL when 1ns after zero.
+	Precision truncates written times and sets the written fraction digits. (default time.Second => "2006-01-02 15:04:05")
+	Location converts written and read times to the zone, nil keeps the time as it is. Read text times are parsed in Location, or UTC.
+	time.Time codec, zero 일 때 "설정 안됨", zero 에서 1ns 뒤일 때 NULL.
+	Precision 은 기록하는 시간을 자르고 소수점 자리수를 정함. (기본 time.Second => "2006-01-02 15:04:05")
+	Location 은 기록하고 읽는 시간을 해당 zone 으로 변환, nil 이면 그대로 사용. 텍스트로 읽은 시간은 Location 또는 UTC 로 파싱.
+
+	ex)
+		DB_RegisterCodec[time.Time](DB_TimeCodec{Precision: time.Millisecond, Location: time.UTC})	<- "2006-01-02 15:04:05.000" in UTC
+*/
+type DB_TimeCodec struct {
+	Precision time.Duration
+	Location  *time.Location
 }
 
-func DB_INSERT_AutoIncrease[DB_Table interface{}](db *sql.DB, tbl_insert ...DB_Table) (int64, int64, error) {
+func (DB_TimeCodec) IsUse(val reflect.Value) bool {
+	return false == val.Interface().(time.Time).IsZero()
+}
 
-	_, err := isValid_insert(tbl_insert...)
-	if err != nil {
-		return 0, 0, err
+func (DB_TimeCodec) Init(val reflect.Value) { val.Set(reflect.ValueOf(time.Time{})) }
+
+var db_nullTime = time.Time{}.Add(time.Nanosecond)
+
+func (DB_TimeCodec) SetNull(val reflect.Value) { val.Set(reflect.ValueOf(db_nullTime)) }
+
+func (DB_TimeCodec) IsNull(val reflect.Value) bool {
+	return val.Interface().(time.Time).Equal(db_nullTime)
+}
+
+func (c DB_TimeCodec) Encode(val reflect.Value) (interface{}, error) {
+	t := val.Interface().(time.Time)
+	if c.Location != nil {
+		t = t.In(c.Location)
 	}
 
-	queryStr, err := db_Make_INSERT_Query(tbl_insert...)
-	if err != nil {
-		return 0, 0, err
+	layout := "2006-01-02 15:04:05"
+	if 0 < c.Precision && c.Precision < time.Second {
+		t = t.Truncate(c.Precision)
+		digits := 0
+		for p := time.Second; p > c.Precision && digits < 9; p /= 10 {
+			digits++
+		}
+		layout += "." + strings.Repeat("0", digits)
+	} else {
+		t = t.Truncate(time.Second)
 	}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Exec error - ", err)
-		return 0, 0, err
+	return t.Format(layout), nil
+}
+
+func (c DB_TimeCodec) Decode(val reflect.Value, src interface{}) error {
+	var t time.Time
+	var err error
+
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
 	}
 
-	affect, err := res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - ", err)
+	switch s := src.(type) {
+	case time.Time:
+		t = s
+	case []byte:
+		t, err = time.ParseInLocation("2006-01-02 15:04:05.999999999", string(s), loc)
+	case string:
+		t, err = time.ParseInLocation("2006-01-02 15:04:05.999999999", s, loc)
+	default:
+		return db_DecodeError(src, val)
 	}
 
-	lastInsertID, err := res.LastInsertId()
 	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows LastInsertId error - ", err)
+		return db_DecodeError(src, val)
 	}
 
-	return lastInsertID, affect, err
+	if c.Location != nil {
+		t = t.In(c.Location)
+	}
+
+	val.Set(reflect.ValueOf(t))
+	return nil
 }
 
-func DB_UPDATE[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, raw_condition ...string) (int64, error) {
+func DB_InitTable(tbls ...interface{}) {
 
-	/*
-		Check that each table type is the same.
-		각 테이블 타입이 동일한지 체크.
-	*/
-	{
-		tb_col_t := reflect.TypeOf(&tbl_target)
-		tb_where_t := reflect.TypeOf(&tbl_where)
+	for _, t := range tbls {
 
-		if tb_col_t.Name() != tb_where_t.Name() {
-			logger.Errorf("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name())
-			return 0, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+		tbl_val := reflect.ValueOf(t).Elem()
+		tbl_type := reflect.TypeOf(t).Elem()
+
+		/*
+			A join struct initializes each of its tables.
+			join 구조체는 각 테이블을 초기화.
+		*/
+		if tables, ok := db_JoinTables(tbl_type); ok {
+			for _, tbl := range tables {
+				DB_InitTable(tbl_val.FieldByIndex(tbl.Index).Addr().Interface())
+			}
+			continue
 		}
-	}
 
-	queryStr, err := db_Make_UPDATE_Query(tbl_target, tbl_where, raw_condition...)
-	if err != nil {
-		return 0, err
+		for _, col := range db_Columns(tbl_type) {
+			if codec := db_CodecOf(col.Field.Type); codec != nil {
+				codec.Init(tbl_val.FieldByIndex(col.Index))
+			}
+		}
 	}
+}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
-		return 0, err
-	}
+func DB_NewTable[TableType interface{}](tbl TableType, count int) []TableType {
+	var ret []TableType
 
-	affect, err := res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	for i := 0; i < count; i++ {
+		var obj TableType
+		DB_InitTable(&obj)
+		ret = append(ret, obj)
 	}
 
-	return affect, err
+	return ret
 }
 
-func DB_DELETE[DB_Table interface{}](db *sql.DB, tbl_where DB_Table, raw_condition ...string) (int64, error) {
+/*
+	SQL dialect of a database. Identifier quoting, placeholder style, UPSERT / RETURNING / LIMIT syntax differ per DBMS.
+	Builders always write '?' placeholders, and Rebind changes them to the dialect style at the end. (ex. PostgreSQL $1, $2 ...)
+	데이터베이스의 SQL 방언. 식별자 quoting, placeholder 형태, UPSERT / RETURNING / LIMIT 문법이 DBMS 마다 다름.
+	쿼리 생성 함수는 항상 '?' placeholder 로 작성하고, 마지막에 Rebind 로 방언 형태로 변경. (ex. PostgreSQL $1, $2 ...)
+*/
+type DB_Dialect interface {
+	Name() string
+	Quote(ident string) string
+	Rebind(query string) string
 
-	queryStr, err := db_Make_DELETE_Query(tbl_where, raw_condition...)
-	if err != nil {
-		return 0, err
-	}
+	/*
+		Conflict clause appended after INSERT ... VALUES (...).
+		bindUpdate == true means each update column takes one more '?' with the inserted value. (MySQL)
+		INSERT ... VALUES (...) 뒤에 붙는 conflict 구문.
+		bindUpdate == true 면 각 update 컬럼마다 INSERT 값으로 '?' 를 하나씩 더 받음. (MySQL)
+	*/
+	Upsert(pk_columns []string, update_columns []string) (clause string, bindUpdate bool, err error)
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		return 0, err
+	/*
+		" RETURNING col, ..." or "" when the dialect has no RETURNING.
+		" RETURNING col, ..." 또는 RETURNING 이 없는 방언이면 "".
+	*/
+	Returning(columns []string) string
+	SupportsLastInsertId() bool
+	LimitOffset(limit int64, offset int64) string
+}
+
+type db_MySQLDialect struct{}
+type db_PostgreSQLDialect struct{}
+type db_SQLiteDialect struct{}
+
+var (
+	DB_MySQL      DB_Dialect = db_MySQLDialect{}
+	DB_PostgreSQL DB_Dialect = db_PostgreSQLDialect{}
+	DB_SQLite     DB_Dialect = db_SQLiteDialect{}
+
+	DB_DefaultDialect DB_Dialect = DB_MySQL
+)
+
+func (db_MySQLDialect) Name() string { return "mysql" }
+
+func (db_MySQLDialect) Quote(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+func (db_MySQLDialect) Rebind(query string) string { return query }
+
+func (d db_MySQLDialect) Upsert(pk_columns []string, update_columns []string) (string, bool, error) {
+	var set_elems []string
+	for _, col := range update_columns {
+		set_elems = append(set_elems, col+"=?")
 	}
 
-	affect, err := res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	/*
+		Every column is PK, so nothing to update. Assign PK to itself to keep the statement valid.
+		모든 컬럼이 PK 라 UPDATE 할 것이 없음. 구문이 유효하도록 PK 를 자기 자신으로 대입.
+	*/
+	if 0 == len(set_elems) && 0 < len(pk_columns) {
+		return " ON DUPLICATE KEY UPDATE " + pk_columns[0] + "=" + pk_columns[0], false, nil
 	}
 
-	return affect, err
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(set_elems, ", "), true, nil
 }
 
-func DB_UPSERT[DB_Table interface{}](db *sql.DB, tbl_upsert DB_Table) (int64, error) {
+func (db_MySQLDialect) Returning(columns []string) string { return "" }
 
-	queryStr, err := db_Make_UPSERT_Query(tbl_upsert)
-	if err != nil {
-		return 0, err
+func (db_MySQLDialect) SupportsLastInsertId() bool { return true }
+
+func (db_MySQLDialect) LimitOffset(limit int64, offset int64) string {
+	switch {
+	case 0 <= limit && 0 < offset:
+		return fmt.Sprint(" LIMIT ", limit, " OFFSET ", offset)
+	case 0 <= limit:
+		return fmt.Sprint(" LIMIT ", limit)
+	case 0 < offset:
+		// MySQL has no OFFSET without LIMIT. MySQL 은 LIMIT 없는 OFFSET 이 없음.
+		return fmt.Sprint(" LIMIT 18446744073709551615 OFFSET ", offset)
 	}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		return 0, err
+	return ""
+}
+
+func (db_PostgreSQLDialect) Name() string { return "postgres" }
+
+func (db_PostgreSQLDialect) Quote(ident string) string {
+	return "\"" + strings.ReplaceAll(ident, "\"", "\"\"") + "\""
+}
+
+func (db_PostgreSQLDialect) Rebind(query string) string {
+	return db_RebindNumbered(query, "$")
+}
+
+func (db_PostgreSQLDialect) Upsert(pk_columns []string, update_columns []string) (string, bool, error) {
+	return db_UpsertOnConflict(pk_columns, update_columns)
+}
+
+func (db_PostgreSQLDialect) Returning(columns []string) string {
+	return " RETURNING " + strings.Join(columns, ", ")
+}
+
+func (db_PostgreSQLDialect) SupportsLastInsertId() bool { return false }
+
+func (db_PostgreSQLDialect) LimitOffset(limit int64, offset int64) string {
+	var str string
+	if 0 <= limit {
+		str += fmt.Sprint(" LIMIT ", limit)
+	}
+	if 0 < offset {
+		str += fmt.Sprint(" OFFSET ", offset)
 	}
 
-	affect, err := res.RowsAffected()
+	return str
+}
+
+func (db_SQLiteDialect) Name() string { return "sqlite" }
+
+func (db_SQLiteDialect) Quote(ident string) string {
+	return "\"" + strings.ReplaceAll(ident, "\"", "\"\"") + "\""
+}
+
+func (db_SQLiteDialect) Rebind(query string) string { return query }
+
+func (db_SQLiteDialect) Upsert(pk_columns []string, update_columns []string) (string, bool, error) {
+	return db_UpsertOnConflict(pk_columns, update_columns)
+}
+
+func (db_SQLiteDialect) Returning(columns []string) string {
+	return " RETURNING " + strings.Join(columns, ", ")
+}
+
+func (db_SQLiteDialect) SupportsLastInsertId() bool { return true }
+
+func (db_SQLiteDialect) LimitOffset(limit int64, offset int64) string {
+	switch {
+	case 0 <= limit && 0 < offset:
+		return fmt.Sprint(" LIMIT ", limit, " OFFSET ", offset)
+	case 0 <= limit:
+		return fmt.Sprint(" LIMIT ", limit)
+	case 0 < offset:
+		// SQLite needs LIMIT before OFFSET, -1 is "no limit". SQLite 는 OFFSET 앞에 LIMIT 이 필요, -1 은 "제한 없음".
+		return fmt.Sprint(" LIMIT -1 OFFSET ", offset)
+	}
+
+	return ""
+}
+
+/*
+	A dialect may implement DB_RetryableDialect to tell DBJob which errors are worth running the jobs again. (deadlock, lock wait timeout)
+	방언은 DB_RetryableDialect 를 구현해, job 을 다시 실행할 만한 에러를 DBJob 에 알려줄 수 있음. (deadlock, lock wait timeout)
+*/
+type DB_RetryableDialect interface {
+	IsRetryable(err error) bool
+}
+
+func db_IsRetryable(d DB_Dialect, err error) bool {
+	if retryable, ok := d.(DB_RetryableDialect); ok {
+		return retryable.IsRetryable(err)
+	}
+
+	return false
+}
+
+/*
+	MySQL 1213 : deadlock, 1205 : lock wait timeout.
+*/
+func (db_MySQLDialect) IsRetryable(err error) bool {
+	var mysql_err *mysql.MySQLError
+	if false == errors.As(err, &mysql_err) {
+		return false
+	}
+
+	return mysql_err.Number == 1213 || mysql_err.Number == 1205
+}
+
+/*
+	PostgreSQL 40001 : serialization failure, 40P01 : deadlock, 55P03 : lock timeout.
+*/
+func (db_PostgreSQLDialect) IsRetryable(err error) bool {
+	var state interface{ SQLState() string }
+	if false == errors.As(err, &state) {
+		return false
+	}
+
+	switch state.SQLState() {
+	case "40001", "40P01", "55P03":
+		return true
+	}
+
+	return false
+}
+
+/*
+	SQLite SQLITE_BUSY / SQLITE_LOCKED.
+*/
+func (db_SQLiteDialect) IsRetryable(err error) bool {
+	var sqlite_err sqlite3.Error
+	if false == errors.As(err, &sqlite_err) {
+		return false
+	}
+
+	return sqlite_err.Code == sqlite3.ErrBusy || sqlite_err.Code == sqlite3.ErrLocked
+}
+
+func db_UpsertOnConflict(pk_columns []string, update_columns []string) (string, bool, error) {
+	if 1 > len(pk_columns) {
+		return "", false, errors.New("[ SQL ERROR ] UPSERT needs PK:\"true\" columns for ON CONFLICT")
+	}
+
+	if 1 > len(update_columns) {
+		return " ON CONFLICT (" + strings.Join(pk_columns, ", ") + ") DO NOTHING", false, nil
+	}
+
+	var set_elems []string
+	for _, col := range update_columns {
+		set_elems = append(set_elems, col+"=EXCLUDED."+col)
+	}
+
+	return " ON CONFLICT (" + strings.Join(pk_columns, ", ") + ") DO UPDATE SET " + strings.Join(set_elems, ", "), false, nil
+}
+
+/*
+	Change '?' placeholders to numbered ones ($1, $2 ...), skipping '?' inside quoted strings and identifiers.
+	'?' placeholder 를 번호 형태 ($1, $2 ...) 로 변경, 따옴표 문자열과 식별자 안의 '?' 는 제외.
+*/
+func db_RebindNumbered(query string, prefix string) string {
+	var sb strings.Builder
+	var quote rune
+	n := 0
+
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteString(prefix + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
+
+/*
+	What every DB_* function runs on, satisfied by *sql.DB, *sql.Tx and *sql.Conn.
+	So DB_* functions work inside a transaction opened by the caller, or on a pinned connection. (session variables, GET_LOCK)
+	모든 DB_* 함수가 실행되는 대상이며, *sql.DB, *sql.Tx, *sql.Conn 이 만족함.
+	따라서 DB_* 함수를 호출자가 연 트랜잭션 안이나, 고정된 커넥션 위에서 사용 가능. (세션 변수, GET_LOCK)
+
+	ex)
+		tx, _ := db.BeginTx(ctx, nil)
+		DB_UPDATE_Context(ctx, tx, tbl_target, tbl_where)
+		DB_INSERT_Context(ctx, tx, tbl_log)
+		tx.Commit()
+*/
+type DB_Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	db_dialectLock sync.RWMutex
+	db_dialectMap  = make(map[DB_Executor]DB_Dialect)
+)
+
+/*
+	Select the dialect used for a *sql.DB (or a long lived *sql.Conn). One without a registered dialect uses DB_DefaultDialect. (MySQL)
+	A *sql.Tx is short lived, so it is not registered. Wrap it with DB_WithDialect, or set DB_DefaultDialect when only one kind of database is used.
+	*sql.DB (또는 오래 사용하는 *sql.Conn) 에 사용할 방언을 지정. 등록되지 않은 대상은 DB_DefaultDialect 를 사용. (MySQL)
+	*sql.Tx 는 수명이 짧으므로 등록하지 않음. DB_WithDialect 로 감싸거나, 한 종류의 DB 만 사용하면 DB_DefaultDialect 를 지정.
+
+	ex)
+		pg, _ := sql.Open("postgres", "connection string")
+		DB_SetDialect(pg, DB_PostgreSQL)
+
+		tx, _ := pg.BeginTx(ctx, nil)
+		DB_UPDATE_Context(ctx, DB_WithDialect(tx, DB_PostgreSQL), tbl_target, tbl_where)
+*/
+func DB_SetDialect(db DB_Executor, d DB_Dialect) {
+	db_dialectLock.Lock()
+	defer db_dialectLock.Unlock()
+
+	db_dialectMap[db] = d
+}
+
+type db_DialectExecutor struct {
+	DB_Executor
+	dialect DB_Dialect
+}
+
+func DB_WithDialect(db DB_Executor, d DB_Dialect) DB_Executor {
+	return db_DialectExecutor{DB_Executor: db, dialect: d}
+}
+
+func db_DialectOf(db DB_Executor) DB_Dialect {
+	if with, ok := db.(db_DialectExecutor); ok {
+		return with.dialect
+	}
+	if dry, ok := db.(*DB_DryRun); ok {
+		return dry.dialect
+	}
+
+	db_dialectLock.RLock()
+	defer db_dialectLock.RUnlock()
+
+	if d, ok := db_dialectMap[db]; ok {
+		return d
+	}
+
+	return DB_DefaultDialect
+}
+
+/*
+	A failure caused by ctx (deadline, cancel) is returned as ctx.Err(),
+	so errors.Is(err, context.DeadlineExceeded) / errors.Is(err, context.Canceled) tells it from a SQL error.
+	ctx 로 인한 실패 (deadline, cancel) 는 ctx.Err() 로 반환하므로,
+	errors.Is(err, context.DeadlineExceeded) / errors.Is(err, context.Canceled) 로 SQL 에러와 구분 가능.
+*/
+func db_ContextError(ctx context.Context, err error) error {
+	if ctx_err := ctx.Err(); ctx_err != nil {
+		return ctx_err
+	}
+
+	return err
+}
+
+/*
+	Table name comes from TableName() when the table struct implements it, otherwise the Go type name is used.
+	A "schema.table" form is allowed to point at another database. (ex. "gamedb01.tblcharacter")
+	테이블 구조체가 TableName() 을 구현하면 그 이름을, 아니면 Go 타입 이름을 사용.
+	다른 database 를 가리키기 위해 "schema.table" 형태도 허용. (ex. "gamedb01.tblcharacter")
+
+	ex)
+		type Account struct {
+			PlayerKey string `db:"player_key" PK:"true"`
+			UserUUID  int64  `db:"user_uuid"`
+			Memo      string `db:"-"`
+		}
+
+		func (Account) TableName() string { return "accountdb.tblaccount" }
+
+		DB_SELECT(db, tbl_select, tbl_where)	<- SELECT `player_key` FROM `accountdb`.`tblaccount` WHERE `user_uuid` = ?;
+*/
+type DB_TableNamer interface {
+	TableName() string
+}
+
+/*
+	Column metadata of a table struct field.
+	Column name comes from the `db:"column_name"` tag, otherwise the field name is used. `db:"-"` fields are not columns.
+	Index is the field index path, it goes through embedded / inline structs.
+	테이블 구조체 필드의 컬럼 정보.
+	컬럼명은 `db:"column_name"` 태그를, 없으면 필드명을 사용. `db:"-"` 필드는 컬럼이 아님.
+	Index 는 필드 인덱스 경로이며, embedded / inline 구조체를 거쳐감.
+*/
+type db_ColumnInfo struct {
+	Index []int
+	Name  string
+	Field reflect.StructField
+}
+
+func db_QuoteTable(d DB_Dialect, name string) string {
+	var quoted []string
+	for _, part := range strings.Split(name, ".") {
+		quoted = append(quoted, d.Quote(part))
+	}
+
+	return strings.Join(quoted, ".")
+}
+
+func db_TableName(tbl_type reflect.Type) string {
+	if namer, ok := reflect.Zero(tbl_type).Interface().(DB_TableNamer); ok {
+		return namer.TableName()
+	}
+
+	return tbl_type.Name()
+}
+
+/*
+	Struct groups of columns are flattened into the table columns.
+	An embedded struct is always flattened, a named struct field only with the "inline" tag option, otherwise it is not a column.
+	The tag name becomes the prefix of the flattened column names.
+	구조체로 묶인 컬럼 그룹은 테이블 컬럼으로 펼쳐짐.
+	embedded 구조체는 항상, 이름이 있는 구조체 필드는 "inline" 태그 옵션이 있을 때만 펼쳐지며, 아니면 컬럼이 아님.
+	태그 이름은 펼쳐진 컬럼명의 prefix 가 됨.
+
+	ex)
+		type Timestamps struct {
+			CreateTime time.Time
+			UpdateTime time.Time
+		}
+
+		type tblcharacter struct {
+			CharID int64
+			Timestamps							<- CreateTime, UpdateTime
+			Stat   CharStat `db:"stat_,inline"`	<- stat_HP, stat_MP
+		}
+*/
+func db_Columns(tbl_type reflect.Type) []db_ColumnInfo {
+	return db_ColumnsPrefix(tbl_type, nil, "")
+}
+
+func db_ColumnsPrefix(tbl_type reflect.Type, index []int, prefix string) []db_ColumnInfo {
+	var columns []db_ColumnInfo
+
+	for i := 0; i < tbl_type.NumField(); i++ {
+		t := tbl_type.Field(i)
+		if false == t.IsExported() && (false == t.Anonymous || t.Type.Kind() != reflect.Struct) {
+			continue
+		}
+
+		name := t.Name
+		var tag_name, tag_option string
+		if tag, ok := t.Tag.Lookup("db"); ok {
+			tag_name, tag_option, _ = strings.Cut(tag, ",")
+			if tag_name == "-" {
+				continue
+			}
+			if tag_name != "" {
+				name = tag_name
+			}
+		}
+
+		field_index := append(append([]int{}, index...), t.Index...)
+
+		if t.Type.Kind() == reflect.Struct && false == db_IsValueStruct(t.Type) {
+			if true == t.Anonymous || tag_option == "inline" {
+				columns = append(columns, db_ColumnsPrefix(t.Type, field_index, prefix+tag_name)...)
+			}
+			continue
+		}
+
+		columns = append(columns, db_ColumnInfo{Index: field_index, Name: prefix + name, Field: t})
+	}
+
+	return columns
+}
+
+/*
+	Struct types stored as one column value. (registered codec types like time.Time, DB_Field, sql.Null*, driver.Valuer / sql.Scanner types)
+	하나의 컬럼 값으로 저장되는 구조체 타입. (time.Time 등 codec 이 등록된 타입, DB_Field, sql.Null*, driver.Valuer / sql.Scanner 타입)
+*/
+func db_IsValueStruct(t reflect.Type) bool {
+	db_codecMutex.RLock()
+	_, ok := db_codecs[t]
+	db_codecMutex.RUnlock()
+	if true == ok {
+		return true
+	}
+
+	return t.Implements(db_columnType) || t.Implements(db_valuerType) || true == db_IsScannerType(t)
+}
+
+/*
+	Columns whose value is set on the table object.
+	테이블 객체에 값이 설정된 컬럼들.
+*/
+func db_UsedColumns(tbl_val reflect.Value) []db_ColumnInfo {
+	var used []db_ColumnInfo
+	for _, col := range db_Columns(tbl_val.Type()) {
+		if true == db_IsUse(tbl_val.FieldByIndex(col.Index)) {
+			used = append(used, col)
+		}
+	}
+	return used
+}
+
+func db_FindColumn(tbl_type reflect.Type, name string) (db_ColumnInfo, bool) {
+	for _, col := range db_Columns(tbl_type) {
+		if col.Name == name || col.Field.Name == name {
+			return col, true
+		}
+	}
+
+	return db_ColumnInfo{}, false
+}
+
+/*
+	Typed WHERE condition. Values are always bound as placeholders, and column names are checked against the table struct.
+	A column can be given by its column name or its Go field name.
+	타입이 있는 WHERE 조건. 값은 항상 placeholder 로 바인딩되며, 컬럼명은 테이블 구조체 기준으로 검사.
+	컬럼은 컬럼명 또는 Go 필드명으로 지정 가능.
+
+	ex)
+		DB_SELECT(db, tbl_select, tbl_where,
+			DB_Or(DB_Gt("UserUUID", 100), DB_In("GameDBID", 1, 2, 3)),
+			DB_Like("SnsID", "guest_%"),
+			"ORDER BY UserUUID DESC")
+
+		<- SELECT ... WHERE `ConnectIP` = ? AND (`UserUUID` > ? OR `GameDBID` IN (?, ?, ?)) AND `SnsID` LIKE ? ORDER BY UserUUID DESC;
+*/
+type DB_Cond interface {
+	db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error)
+}
+
+type db_ColumnResolver func(name string) (string, error)
+
+type db_CompareCond struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+type db_InCond struct {
+	column string
+	values []interface{}
+	not    bool
+}
+
+type db_BetweenCond struct {
+	column string
+	from   interface{}
+	to     interface{}
+}
+
+type db_NullCond struct {
+	column string
+	not    bool
+}
+
+type db_GroupCond struct {
+	op    string
+	conds []DB_Cond
+}
+
+type db_NotCond struct {
+	cond DB_Cond
+}
+
+type db_ExprCond struct {
+	expr string
+	args []interface{}
+}
+
+func DB_Eq(column string, value interface{}) DB_Cond { return db_CompareCond{column, "=", value} }
+func DB_Ne(column string, value interface{}) DB_Cond { return db_CompareCond{column, "<>", value} }
+func DB_Gt(column string, value interface{}) DB_Cond { return db_CompareCond{column, ">", value} }
+func DB_Ge(column string, value interface{}) DB_Cond { return db_CompareCond{column, ">=", value} }
+func DB_Lt(column string, value interface{}) DB_Cond { return db_CompareCond{column, "<", value} }
+func DB_Le(column string, value interface{}) DB_Cond { return db_CompareCond{column, "<=", value} }
+
+func DB_Like(column string, pattern string) DB_Cond {
+	return db_CompareCond{column, "LIKE", pattern}
+}
+
+func DB_NotLike(column string, pattern string) DB_Cond {
+	return db_CompareCond{column, "NOT LIKE", pattern}
+}
+
+func DB_In(column string, values ...interface{}) DB_Cond {
+	return db_InCond{column: column, values: values}
+}
+
+func DB_NotIn(column string, values ...interface{}) DB_Cond {
+	return db_InCond{column: column, values: values, not: true}
+}
+
+func DB_Between(column string, from interface{}, to interface{}) DB_Cond {
+	return db_BetweenCond{column, from, to}
+}
+
+func DB_IsNull(column string) DB_Cond    { return db_NullCond{column: column} }
+func DB_IsNotNull(column string) DB_Cond { return db_NullCond{column: column, not: true} }
+
+func DB_And(conds ...DB_Cond) DB_Cond { return db_GroupCond{"AND", conds} }
+func DB_Or(conds ...DB_Cond) DB_Cond  { return db_GroupCond{"OR", conds} }
+func DB_Not(cond DB_Cond) DB_Cond     { return db_NotCond{cond} }
+
+/*
+	Hand written SQL expression with '?' placeholders, for what the other conditions cannot express.
+	다른 조건들로 표현할 수 없는 경우를 위한, '?' placeholder 를 사용하는 직접 작성 SQL 식.
+
+	ex) DB_Expr("`Gold` >= `Price` * ?", 2)
+*/
+func DB_Expr(expr string, args ...interface{}) DB_Cond {
+	return db_ExprCond{expr, args}
+}
+
+func db_ToCondArg(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if arg := db_ToArg(reflect.ValueOf(value)); arg != nil {
+		return arg
+	}
+
+	return value
+}
+
+func (c db_CompareCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	col, err := column(c.column)
 	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+		return "", nil, err
+	}
+
+	return col + " " + c.op + " ?", []interface{}{db_ToCondArg(c.value)}, nil
+}
+
+func (c db_InCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	col, err := column(c.column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	/*
+		Empty IN list matches nothing, empty NOT IN list matches everything.
+		빈 IN 목록은 아무것도 만족하지 않고, 빈 NOT IN 목록은 모두 만족.
+	*/
+	if 1 > len(c.values) {
+		if true == c.not {
+			return "1=1", nil, nil
+		}
+		return "1=0", nil, nil
+	}
+
+	var marks []string
+	var args []interface{}
+	for _, v := range c.values {
+		marks = append(marks, "?")
+		args = append(args, db_ToCondArg(v))
+	}
+
+	op := " IN ("
+	if true == c.not {
+		op = " NOT IN ("
+	}
+
+	return col + op + strings.Join(marks, ", ") + ")", args, nil
+}
+
+func (c db_BetweenCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	col, err := column(c.column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return col + " BETWEEN ? AND ?", []interface{}{db_ToCondArg(c.from), db_ToCondArg(c.to)}, nil
+}
+
+func (c db_NullCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	col, err := column(c.column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if true == c.not {
+		return col + " IS NOT NULL", nil, nil
+	}
+
+	return col + " IS NULL", nil, nil
+}
+
+func (c db_GroupCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	if 1 > len(c.conds) {
+		if c.op == "OR" {
+			return "1=0", nil, nil
+		}
+		return "1=1", nil, nil
+	}
+
+	var elems []string
+	var args []interface{}
+	for _, cond := range c.conds {
+		str, cond_args, err := cond.db_Build(d, column)
+		if err != nil {
+			return "", nil, err
+		}
+		elems = append(elems, str)
+		args = append(args, cond_args...)
+	}
+
+	return "(" + strings.Join(elems, " "+c.op+" ") + ")", args, nil
+}
+
+func (c db_NotCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	str, args, err := c.cond.db_Build(d, column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "NOT (" + str + ")", args, nil
+}
+
+func (c db_ExprCond) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	var args []interface{}
+	for _, v := range c.args {
+		args = append(args, db_ToCondArg(v))
+	}
+
+	return "(" + c.expr + ")", args, nil
+}
+
+/*
+	Resolve a column given by column name or Go field name to the quoted column name of the table struct.
+	컬럼명 또는 Go 필드명으로 주어진 컬럼을, 테이블 구조체의 quoting 된 컬럼명으로 변환.
+*/
+func db_TableColumnResolver(d DB_Dialect, tbl_type reflect.Type) db_ColumnResolver {
+	return func(name string) (string, error) {
+		col, ok := db_FindColumn(tbl_type, name)
+		if false == ok {
+			return "", errors.New(fmt.Sprint("[ SQL ERROR ] Unknown column - ", name, " in ", db_TableName(tbl_type)))
+		}
+
+		return d.Quote(col.Name), nil
+	}
+}
+
+/*
+	Typed SELECT options, passed in conditions next to DB_Cond. Columns are checked against the table struct,
+	so a typo is reported before the query is sent.
+	DB_Cond 와 함께 conditions 로 전달하는 타입이 있는 SELECT 옵션. 컬럼은 테이블 구조체 기준으로 검사하므로,
+	오타는 쿼리를 보내기 전에 에러로 보고됨.
+
+	ex)
+		DB_SELECT(db, tbl_select, tbl_where, DB_OrderBy("UserUUID", DB_DESC), DB_Limit(2))
+		<- SELECT ... WHERE `ConnectIP` = ? ORDER BY `UserUUID` DESC LIMIT 2;
+*/
+type DB_Option interface {
+	db_Apply(opts *db_QueryOptions)
+}
+
+type DB_OrderDir string
+
+const (
+	DB_ASC  DB_OrderDir = "ASC"
+	DB_DESC DB_OrderDir = "DESC"
+)
+
+type db_OrderBy struct {
+	column string
+	dir    DB_OrderDir
+}
+
+type db_QueryOptions struct {
+	distinct bool
+	group_by []string
+	having   []DB_Cond
+	order_by []db_OrderBy
+	limit    int64
+	offset   int64
+	lock     DB_LockMode
+	wait     DB_LockWait
+}
+
+type db_OptionFunc func(opts *db_QueryOptions)
+
+func (f db_OptionFunc) db_Apply(opts *db_QueryOptions) { f(opts) }
+
+func DB_OrderBy(column string, dir DB_OrderDir) DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) {
+		opts.order_by = append(opts.order_by, db_OrderBy{column, dir})
+	})
+}
+
+func DB_Limit(limit int64) DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.limit = limit })
+}
+
+func DB_Offset(offset int64) DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.offset = offset })
+}
+
+func DB_Distinct() DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.distinct = true })
+}
+
+/*
+	< Row lock >
+	Locks the selected rows until the end of the transaction, so it is used in a transaction. (ex. ADD_SELECT of DBJob)
+	SKIP LOCKED skips rows locked by others, NOWAIT fails at once instead of waiting for them.
+	선택된 row 를 트랜잭션 끝까지 잠그므로, 트랜잭션 안에서 사용. (ex. DBJob 의 ADD_SELECT)
+	SKIP LOCKED 는 다른 곳에서 잠근 row 를 건너뛰고, NOWAIT 는 기다리지 않고 바로 실패.
+
+	ex)
+		DB_SELECT_Context(ctx, tx, tbl_select, tbl_where, DB_ForUpdate(), DB_SkipLocked(), DB_Limit(10))
+		=> MySQL : ... LIMIT 10 FOR UPDATE SKIP LOCKED
+*/
+type DB_LockMode string
+type DB_LockWait string
+
+const (
+	DB_LOCK_UPDATE DB_LockMode = "UPDATE"
+	DB_LOCK_SHARE  DB_LockMode = "SHARE"
+
+	DB_LOCK_SKIP_LOCKED DB_LockWait = "SKIP LOCKED"
+	DB_LOCK_NOWAIT      DB_LockWait = "NOWAIT"
+)
+
+func DB_ForUpdate() DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.lock = DB_LOCK_UPDATE })
+}
+
+func DB_ForShare() DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.lock = DB_LOCK_SHARE })
+}
+
+func DB_SkipLocked() DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.wait = DB_LOCK_SKIP_LOCKED })
+}
+
+func DB_NoWait() DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) { opts.wait = DB_LOCK_NOWAIT })
+}
+
+/*
+	A dialect implements DB_LockingDialect to support DB_ForUpdate / DB_ForShare. wait is "" when not given.
+	방언은 DB_LockingDialect 를 구현해 DB_ForUpdate / DB_ForShare 를 지원. wait 는 지정하지 않으면 "".
+*/
+type DB_LockingDialect interface {
+	Lock(mode DB_LockMode, wait DB_LockWait) (string, error)
+}
+
+func (db_MySQLDialect) Lock(mode DB_LockMode, wait DB_LockWait) (string, error) {
+	/*
+		LOCK IN SHARE MODE works on every version, FOR SHARE (8.0) is needed only with SKIP LOCKED / NOWAIT.
+		LOCK IN SHARE MODE 는 모든 버전에서 동작하며, FOR SHARE (8.0) 는 SKIP LOCKED / NOWAIT 와 함께일 때만 필요.
+	*/
+	if mode == DB_LOCK_SHARE && wait == "" {
+		return " LOCK IN SHARE MODE", nil
+	}
+
+	return db_LockClause(mode, wait), nil
+}
+
+func (db_PostgreSQLDialect) Lock(mode DB_LockMode, wait DB_LockWait) (string, error) {
+	return db_LockClause(mode, wait), nil
+}
+
+/*
+	SQLite has no row lock, a write transaction already locks the whole database.
+	SQLite 는 row lock 이 없으며, 쓰기 트랜잭션이 이미 DB 전체를 잠금.
+*/
+func (db_SQLiteDialect) Lock(mode DB_LockMode, wait DB_LockWait) (string, error) {
+	if wait != "" {
+		return "", errors.New(fmt.Sprint("[ SQL ERROR ] sqlite does not support ", wait))
+	}
+
+	return "", nil
+}
+
+func db_LockClause(mode DB_LockMode, wait DB_LockWait) string {
+	str := " FOR " + string(mode)
+	if wait != "" {
+		str += " " + string(wait)
+	}
+
+	return str
+}
+
+func DB_GroupBy(columns ...string) DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) {
+		opts.group_by = append(opts.group_by, columns...)
+	})
+}
+
+/*
+	HAVING conditions, ANDed together. Aggregates are written with DB_Expr.
+	HAVING 조건, AND 로 연결됨. 집계 함수는 DB_Expr 로 작성.
+
+	ex)
+		DB_Having(DB_Expr("COUNT(*) > ?", 10))
+*/
+func DB_Having(conds ...DB_Cond) DB_Option {
+	return db_OptionFunc(func(opts *db_QueryOptions) {
+		opts.having = append(opts.having, conds...)
+	})
+}
+
+/*
+	Separate DB_Option out of conditions. The rest are WHERE conditions.
+	conditions 에서 DB_Option 을 분리. 나머지는 WHERE 조건.
+*/
+func db_SplitOptions(conditions []interface{}) (db_QueryOptions, []interface{}) {
+	opts := db_QueryOptions{limit: -1}
+	var rest []interface{}
+
+	for _, condition := range conditions {
+		if opt, ok := condition.(DB_Option); ok {
+			opt.db_Apply(&opts)
+			continue
+		}
+		rest = append(rest, condition)
+	}
+
+	return opts, rest
+}
+
+func (opts db_QueryOptions) db_Build(d DB_Dialect, column db_ColumnResolver) (string, []interface{}, error) {
+	var str string
+	var args []interface{}
+
+	if 0 < len(opts.group_by) {
+		var group_elems []string
+		for _, name := range opts.group_by {
+			col, err := column(name)
+			if err != nil {
+				return "", nil, err
+			}
+			group_elems = append(group_elems, col)
+		}
+		str += " GROUP BY " + strings.Join(group_elems, ", ")
+	}
+
+	if 0 < len(opts.having) {
+		having_str, having_args, err := DB_And(opts.having...).db_Build(d, column)
+		if err != nil {
+			return "", nil, err
+		}
+		str += " HAVING " + having_str
+		args = append(args, having_args...)
+	}
+
+	if 0 < len(opts.order_by) {
+		var order_elems []string
+		for _, order := range opts.order_by {
+			col, err := column(order.column)
+			if err != nil {
+				return "", nil, err
+			}
+			if order.dir != DB_ASC && order.dir != DB_DESC {
+				return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Unknown ORDER BY direction - ", order.dir))
+			}
+			order_elems = append(order_elems, col+" "+string(order.dir))
+		}
+		str += " ORDER BY " + strings.Join(order_elems, ", ")
+	}
+
+	str += d.LimitOffset(opts.limit, opts.offset)
+
+	if opts.lock != "" || opts.wait != "" {
+		locking, ok := d.(DB_LockingDialect)
+		if false == ok {
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Row lock is not supported by dialect - ", d.Name()))
+		}
+		if opts.lock == "" {
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] ", opts.wait, " without DB_ForUpdate / DB_ForShare"))
+		}
+
+		lock_str, err := locking.Lock(opts.lock, opts.wait)
+		if err != nil {
+			return "", nil, err
+		}
+		str += lock_str
+	}
+
+	return str, args, nil
+}
+
+func db_Make_WHERE(d DB_Dialect, tbl_where interface{}, conditions ...interface{}) (string, []interface{}, error) {
+	tbl_val := reflect.ValueOf(tbl_where)
+	queryWhereElems, where_args := db_WhereColumns(d, tbl_val, "")
+
+	return db_Make_WHERE_Conditions(d, queryWhereElems, where_args, db_TableColumnResolver(d, tbl_val.Type()), conditions...)
+}
+
+/*
+	Extract columns and values to be conditioned in WHERE clause, each value is bound as a '?' placeholder.
+	qualifier is put before each column. (ex. "`t0`." in JOIN)
+	WHERE 절의 조건이 될 컬럼과 값을 추출, 각 값은 '?' placeholder 로 바인딩.
+	qualifier 는 각 컬럼 앞에 붙음. (ex. JOIN 의 "`t0`.")
+*/
+func db_WhereColumns(d DB_Dialect, tbl_val reflect.Value, qualifier string) ([]string, []interface{}) {
+	var where_args []interface{}
+	var queryWhereElems []string
+
+	for _, col := range db_Columns(tbl_val.Type()) {
+		reflect_v := tbl_val.FieldByIndex(col.Index)
+		reflect_t := qualifier + d.Quote(col.Name)
+		if true == db_IsUse(reflect_v) {
+			switch db_NullState(reflect_v, col.Field) {
+			case db_FIELD_NULL:
+				queryWhereElems = append(queryWhereElems, reflect_t+" IS NULL")
+			case db_FIELD_NOT_NULL:
+				queryWhereElems = append(queryWhereElems, reflect_t+" IS NOT NULL")
+			default:
+				queryWhereElems = append(queryWhereElems, reflect_t+" = ?")
+				where_args = append(where_args, db_ToArg(reflect_v))
+			}
+		}
+	}
+
+	return queryWhereElems, where_args
+}
+
+func db_Make_WHERE_Conditions(d DB_Dialect, queryWhereElems []string, where_args []interface{}, column db_ColumnResolver, conditions ...interface{}) (string, []interface{}, error) {
+	var where_str string
+
+	/*
+		Each condition is a DB_Cond ANDed into WHERE, or a raw string appended after WHERE as before. (ex. "ORDER BY ...")
+		각 조건은 WHERE 에 AND 로 붙는 DB_Cond 이거나, 기존처럼 WHERE 뒤에 붙는 raw 문자열. (ex. "ORDER BY ...")
+	*/
+	var raw_condition []string
+	for _, condition := range conditions {
+		switch c := condition.(type) {
+		case string:
+			raw_condition = append(raw_condition, c)
+		case DB_Cond:
+			cond_str, cond_args, err := c.db_Build(d, column)
+			if err != nil {
+				logger.Error(err)
+				return "", nil, err
+			}
+			queryWhereElems = append(queryWhereElems, cond_str)
+			where_args = append(where_args, cond_args...)
+		case DB_Option:
+			logger.Error("[ SQL ERROR ] SELECT options can only be used in SELECT")
+			return "", nil, errors.New("[ SQL ERROR ] SELECT options can only be used in SELECT")
+		case DB_Expect:
+			logger.Error("[ SQL ERROR ] DB_Expect can only be used in DBJob")
+			return "", nil, errors.New("[ SQL ERROR ] DB_Expect can only be used in DBJob")
+		default:
+			logger.Error("[ SQL ERROR ] Unknown condition type - ", reflect.TypeOf(condition))
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Unknown condition type - ", reflect.TypeOf(condition)))
+		}
+	}
+
+	if 0 != len(queryWhereElems) {
+		where_str = (" WHERE " + strings.Join(queryWhereElems, " AND "))
+	}
+
+	if 0 < len(raw_condition) {
+		where_str += (" " + strings.Join(raw_condition, " "))
+	}
+
+	return where_str, where_args, nil
+}
+
+func db_Make_SELECT_Query(d DB_Dialect, tbl_columns interface{}, tbl_where interface{}, conditions ...interface{}) (string, []interface{}, error) {
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_columns)); err != nil {
+		return "", nil, err
+	}
+
+	/*
+		Extract the columns that will be affected from the SELECT UPDATE INSERT syntax.
+		SELECT UPDATE INSERT 구문에서 영향 받을 컬럼들부터 추출.
+	*/
+	var target_column []string
+
+	tbl_val := reflect.ValueOf(tbl_columns)
+	from_table := db_QuoteTable(d, db_TableName(tbl_val.Type()))
+
+	for _, col := range db_Columns(tbl_val.Type()) {
+		if true == db_IsUse(tbl_val.FieldByIndex(col.Index)) {
+			target_column = append(target_column, d.Quote(col.Name))
+		}
+	}
+
+	if 0 == len(db_Columns(reflect.TypeOf(tbl_where))) {
+		return "", nil, errors.New("[ SQL ERROR ] There is no SQL WHERE column value.")
+	}
+
+	/*
+		Make Query.
+		쿼리 생성.
+	*/
+	opts, conditions := db_SplitOptions(conditions)
+
+	where_str, where_args, err := db_Make_WHERE(d, tbl_where, conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	option_str, option_args, err := opts.db_Build(d, db_TableColumnResolver(d, tbl_val.Type()))
+	if err != nil {
+		logger.Error(err)
+		return "", nil, err
+	}
+	where_args = append(where_args, option_args...)
+
+	select_str := "SELECT "
+	if true == opts.distinct {
+		select_str += "DISTINCT "
+	}
+
+	queryStr := d.Rebind(select_str + strings.Join(target_column, ", ") + " FROM " + from_table + where_str + option_str + ";")
+
+	return queryStr, where_args, nil
+}
+
+func db_Make_INSERT_Query[DB_Table interface{}](d DB_Dialect, tbl_insert ...DB_Table) (string, []interface{}, error) {
+	head, rows, err := db_Make_INSERT_Rows(d, tbl_insert...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var args []interface{}
+	var tbl_elem_array []string
+	for _, row := range rows {
+		tuple, row_args := db_InsertTuple(row)
+		args = append(args, row_args...)
+		tbl_elem_array = append(tbl_elem_array, tuple)
+	}
+
+	// queryStr's final query form => INSERT INTO tbl (col1, col2, ...) VALUES (?, ?, ...), (?, DEFAULT, ...) ... ;
+	queryStr := d.Rebind(head + strings.Join(tbl_elem_array, ", ") + ";")
+
+	return queryStr, args, nil
+}
+
+/*
+	Parts of INSERT before Rebind. => head "INSERT INTO tbl (col1, col2, ...) VALUES " and the values of each row.
+	A column left unset by a row is db_DefaultValue. (db_InsertTuple writes it as DEFAULT)
+	Rebind 전의 INSERT 구성 요소. => head "INSERT INTO tbl (col1, col2, ...) VALUES " 와 각 row 의 값.
+	row 에서 설정하지 않은 컬럼은 db_DefaultValue. (db_InsertTuple 이 DEFAULT 로 기록)
+*/
+func db_Make_INSERT_Rows[DB_Table interface{}](d DB_Dialect, tbl_insert ...DB_Table) (string, [][]interface{}, error) {
+
+	if 1 > len(tbl_insert) {
+		return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] There is no data for INSERT"))
+	}
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_insert[0])); err != nil {
+		return "", nil, err
+	}
+
+	queryStr := "INSERT INTO "
+	var rows [][]interface{}
+
+	/*
+		This function optionally allows you to attempt to INSERT only a few columns.
+		이 함수에서는 선택적으로 특정 몇몇 컬럼만을 INSERT 하려고 하는 행위를 허용함.
+	*/
+	var valid_columns []db_ColumnInfo
+
+	{
+		/*
+			Extract the table name and each column name.
+			테이블 명과 각 컬럼명을 추출.
+		*/
+		tbl_type := reflect.TypeOf(&tbl_insert[0]).Elem()
+
+		/*
+			A column set in any row is inserted, rows without that column get DEFAULT.
+			SQLite has no DEFAULT in VALUES, so there they get NULL if the column is Null:"true", or it is an error.
+			어느 한 row 에서라도 설정된 컬럼은 INSERT 하며, 해당 컬럼이 없는 row 는 DEFAULT 로 채움.
+			SQLite 는 VALUES 에 DEFAULT 가 없으므로, 컬럼이 Null:"true" 이면 NULL 로 채우고 아니면 에러.
+		*/
+		var field_names []string
+		for _, col := range db_Columns(tbl_type) {
+			for _, tbl_elem := range tbl_insert {
+				if true == db_IsUse(reflect.ValueOf(&tbl_elem).Elem().FieldByIndex(col.Index)) {
+					field_names = append(field_names, d.Quote(col.Name))
+					valid_columns = append(valid_columns, col)
+					break
+				}
+			}
+		}
+
+		/*
+			Change extracted name to query string.
+			추출한 명칭을 쿼리 문자열로 변경.
+		*/
+		queryStr += (db_QuoteTable(d, db_TableName(tbl_type)) + " (")
+		queryStr += (strings.Join(field_names, ", ") + ") VALUES ")
+	}
+	{
+		/*
+			Change each value to be INSERT query form, values are bound as '?' placeholders.
+			INSERT 할 각 값들을 쿼리 형태로 변경, 값은 '?' placeholder 로 바인딩.
+		*/
+		for _, tbl_elem := range tbl_insert {
+			tbl := reflect.ValueOf(&tbl_elem).Elem()
+
+			var row []interface{}
+			for _, col := range valid_columns {
+				val := tbl.FieldByIndex(col.Index)
+				if false == db_IsUse(val) {
+					switch {
+					case d.Name() != "sqlite":
+						row = append(row, db_DefaultValue{})
+					case true == db_IsNullColumn(col.Field):
+						row = append(row, nil)
+					default:
+						return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] SQLite can not INSERT DEFAULT in a multi-row INSERT, set the column in every row - ", col.Name))
+					}
+					continue
+				}
+
+				arg, err := db_ToWriteArg(val, col.Field)
+				if err != nil {
+					return "", nil, err
+				}
+				row = append(row, arg)
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return queryStr, rows, nil
+}
+
+type db_DefaultValue struct{}
+
+/*
+	VALUES tuple of one INSERT row and its args. => "(?, DEFAULT, ?)"
+	INSERT row 하나의 VALUES 튜플과 인자. => "(?, DEFAULT, ?)"
+*/
+func db_InsertTuple(row []interface{}) (string, []interface{}) {
+	values := make([]string, len(row))
+	var args []interface{}
+	for i, v := range row {
+		if _, ok := v.(db_DefaultValue); ok {
+			values[i] = "DEFAULT"
+			continue
+		}
+		values[i] = "?"
+		args = append(args, v)
+	}
+
+	return "(" + strings.Join(values, ", ") + ")", args
+}
+
+func db_Make_UPDATE_Query(d DB_Dialect, tbl_columns interface{}, tbl_where interface{}, conditions ...interface{}) (string, []interface{}, error) {
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_columns)); err != nil {
+		return "", nil, err
+	}
+
+	from_table := db_QuoteTable(d, db_TableName(reflect.TypeOf(tbl_columns)))
+	queryStr := "UPDATE " + from_table + " SET "
+	var args []interface{}
+
+	{
+		var set_field_cmd []string
+		tbl_val := reflect.ValueOf(tbl_columns)
+		for _, col := range db_Columns(tbl_val.Type()) {
+			reflect_v := tbl_val.FieldByIndex(col.Index)
+			reflect_t := d.Quote(col.Name)
+			if true == db_IsUse(reflect_v) {
+				arg, err := db_ToWriteArg(reflect_v, col.Field)
+				if err != nil {
+					return "", nil, err
+				}
+				set_field_cmd = append(set_field_cmd, reflect_t+"=?")
+				args = append(args, arg)
+			}
+		}
+
+		queryStr += strings.Join(set_field_cmd, ", ")
+	}
+
+	{
+		where_str, where_args, err := db_Make_WHERE(d, tbl_where, conditions...)
+		if err != nil {
+			return "", nil, err
+		}
+		queryStr += (where_str + ";")
+		args = append(args, where_args...)
+	}
+
+	queryStr = d.Rebind(queryStr)
+	return queryStr, args, nil
+}
+
+func db_Make_DELETE_Query(d DB_Dialect, tbl_where interface{}, conditions ...interface{}) (string, []interface{}, error) {
+	if err := db_CheckColumns(reflect.TypeOf(tbl_where)); err != nil {
+		return "", nil, err
+	}
+
+	from_table := db_QuoteTable(d, db_TableName(reflect.TypeOf(tbl_where)))
+	queryStr := "DELETE FROM " + from_table
+
+	where_str, args, err := db_Make_WHERE(d, tbl_where, conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+	queryStr += (where_str + ";")
+
+	queryStr = d.Rebind(queryStr)
+	return queryStr, args, nil
+}
+
+func db_Make_UPSERT_Query[DB_Table interface{}](d DB_Dialect, tbl_insert DB_Table) (string, []interface{}, error) {
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_insert)); err != nil {
+		return "", nil, err
+	}
+
+	queryStr := "INSERT INTO "
+	var args []interface{}
+
+	/*
+		This function optionally allows you to attempt to INSERT only a few columns.
+		이 함수에서는 선택적으로 특정 몇몇 컬럼만을 INSERT 하려고 하는 행위를 허용함.
+	*/
+	var valid_columns []db_ColumnInfo
+
+	/*
+		Columns that are not PK are updated on duplicate key, kept as a slice so the placeholder order is fixed.
+		PK 가 아닌 컬럼들은 duplicate key 시 UPDATE 되며, placeholder 순서가 고정되도록 slice 로 보관.
+	*/
+	var not_pk_columns []db_ColumnInfo
+	var pk_names []string
+	var field_names []string
+	var elem_row_value []string
+
+	tbl := reflect.ValueOf(&tbl_insert).Elem()
+	tbl_type := reflect.TypeOf(&tbl_insert).Elem()
+	{
+		/*
+			Extract the table name and each column name.
+			테이블 명과 각 컬럼명을 추출.
+		*/
+		for _, col := range db_Columns(tbl_type) {
+			if true == db_IsUse(tbl.FieldByIndex(col.Index)) {
+				field_names = append(field_names, d.Quote(col.Name))
+				valid_columns = append(valid_columns, col)
+				_, thisIsPK := col.Field.Tag.Lookup("PK")
+				if false == thisIsPK {
+					not_pk_columns = append(not_pk_columns, col)
+				} else {
+					pk_names = append(pk_names, d.Quote(col.Name))
+				}
+			}
+		}
+
+		/*
+			Change extracted name to query string.
+			추출한 명칭을 쿼리 문자열로 변경.
+		*/
+		queryStr += (db_QuoteTable(d, db_TableName(tbl_type)) + " (")
+		queryStr += (strings.Join(field_names, ", ") + ") VALUES ")
+	}
+	{
+		/*
+			Change each value to be INSERT query form, values are bound as '?' placeholders.
+			INSERT 할 각 값들을 쿼리 형태로 변경, 값은 '?' placeholder 로 바인딩.
+		*/
+		for _, col := range valid_columns {
+			arg, err := db_ToWriteArg(tbl.FieldByIndex(col.Index), col.Field)
+			if err != nil {
+				return "", nil, err
+			}
+			elem_row_value = append(elem_row_value, "?")
+			args = append(args, arg)
+		}
+
+		// queryStr's form => INSERT INTO tbl (col1, col2, ...) VALUES (?, ?, ...)
+		queryStr += ("(" + strings.Join(elem_row_value, ", ") + ")")
+	}
+	{
+		/*
+			UPDATE 구문 추가, 방언에 따라 ON DUPLICATE KEY UPDATE 또는 ON CONFLICT ... DO UPDATE
+		*/
+		var update_names []string
+		for _, col := range not_pk_columns {
+			update_names = append(update_names, d.Quote(col.Name))
+		}
+
+		clause, bindUpdate, err := d.Upsert(pk_names, update_names)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if true == bindUpdate {
+			for _, col := range not_pk_columns {
+				arg, err := db_ToWriteArg(tbl.FieldByIndex(col.Index), col.Field)
+				if err != nil {
+					return "", nil, err
+				}
+				args = append(args, arg)
+			}
+		}
+
+		queryStr = d.Rebind(queryStr + clause + ";")
+	}
+
+	return queryStr, args, nil
+}
+
+func db_Make_INCR_Query(d DB_Dialect, tbl_columns interface{}, tbl_where interface{}, size int64, conditions ...interface{}) (string, []interface{}, error) {
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_columns)); err != nil {
+		return "", nil, err
+	}
+
+	from_table := db_QuoteTable(d, db_TableName(reflect.TypeOf(tbl_columns)))
+	queryStr := "UPDATE " + from_table + " SET "
+	var args []interface{}
+
+	{
+		var set_field_cmd []string
+		tbl_val := reflect.ValueOf(tbl_columns)
+		for _, col := range db_Columns(tbl_val.Type()) {
+			reflect_v := tbl_val.FieldByIndex(col.Index)
+			reflect_t := d.Quote(col.Name)
+			if true == db_IsUse(reflect_v) {
+				var operator string
+				var s int64
+				if 0 <= size {
+					operator = "+"
+					s = size
+				} else {
+					operator = "-"
+					s = size * -1
+				}
+				set_field_cmd = append(set_field_cmd, reflect_t+"="+reflect_t+operator+"?")
+				args = append(args, s)
+			}
+		}
+
+		queryStr += strings.Join(set_field_cmd, ", ")
+	}
+
+	{
+		where_str, where_args, err := db_Make_WHERE(d, tbl_where, conditions...)
+		if err != nil {
+			return "", nil, err
+		}
+		queryStr += (where_str + ";")
+		args = append(args, where_args...)
+	}
+
+	queryStr = d.Rebind(queryStr)
+	return queryStr, args, nil
+}
+
+/*
+	Aggregate query. Set columns of tbl_group are selected and grouped, set columns of tbl_target are aggregated by fn.
+	COUNT counts rows, so tbl_target is not used.
+	집계 쿼리. tbl_group 에 설정된 컬럼은 SELECT 후 GROUP BY, tbl_target 에 설정된 컬럼은 fn 으로 집계.
+	COUNT 는 row 수를 세므로 tbl_target 을 사용하지 않음.
+
+	ex) SELECT `GameDBID`, MAX(`UserUUID`) FROM `tblaccount` WHERE `ConnectIP` = ? GROUP BY `GameDBID`;
+*/
+func db_Make_AGGREGATE_Query(d DB_Dialect, fn string, tbl_target interface{}, tbl_group interface{}, tbl_where interface{}, conditions ...interface{}) (string, []interface{}, error) {
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_target)); err != nil {
+		return "", nil, err
+	}
+
+	tbl_val := reflect.ValueOf(tbl_target)
+	from_table := db_QuoteTable(d, db_TableName(tbl_val.Type()))
+
+	var select_elems []string
+	var group_names []string
+	for _, col := range db_UsedColumns(reflect.ValueOf(tbl_group)) {
+		select_elems = append(select_elems, d.Quote(col.Name))
+		group_names = append(group_names, col.Name)
+	}
+
+	if fn == "COUNT" {
+		select_elems = append(select_elems, "COUNT(*)")
+	} else {
+		target_columns := db_UsedColumns(tbl_val)
+		if 0 == len(target_columns) {
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] There is no column for ", fn))
+		}
+		for _, col := range target_columns {
+			for _, name := range group_names {
+				if name == col.Name {
+					return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Column is both grouped and aggregated - ", col.Name))
+				}
+			}
+			select_elems = append(select_elems, fn+"("+d.Quote(col.Name)+")")
+		}
+	}
+
+	if 0 == len(db_Columns(reflect.TypeOf(tbl_where))) {
+		return "", nil, errors.New("[ SQL ERROR ] There is no SQL WHERE column value.")
+	}
+
+	opts, conditions := db_SplitOptions(conditions)
+	if true == opts.distinct {
+		return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] DISTINCT can not be used with ", fn))
+	}
+	opts.group_by = append(group_names, opts.group_by...)
+
+	where_str, args, err := db_Make_WHERE(d, tbl_where, conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	option_str, option_args, err := opts.db_Build(d, db_TableColumnResolver(d, tbl_val.Type()))
+	if err != nil {
+		logger.Error(err)
+		return "", nil, err
+	}
+	args = append(args, option_args...)
+
+	queryStr := d.Rebind("SELECT " + strings.Join(select_elems, ", ") + " FROM " + from_table + where_str + option_str + ";")
+
+	return queryStr, args, nil
+}
+
+/*
+	JOIN between table structs.
+	A join struct has one exported field per table struct, in join order. Each DB_Join joins the next table,
+	so there is one DB_Join per table after the first. Columns are named "Field.Column", the table part can be
+	left out when the column name is only in one table. Tables are aliased as t0, t1, ... so a table can join itself.
+	테이블 구조체 간의 JOIN.
+	join 구조체는 테이블 구조체마다 export 된 필드를 JOIN 순서대로 가짐. 각 DB_Join 은 다음 테이블을 JOIN 하므로,
+	첫 테이블 이후의 테이블마다 DB_Join 이 하나씩 필요. 컬럼명은 "필드.컬럼" 이며, 한 테이블에만 있는 컬럼이면
+	테이블 부분은 생략 가능. 테이블은 t0, t1, ... 으로 alias 되므로 자기 자신과도 JOIN 가능.
+
+	ex)
+		type accountCharacter struct {
+			Account   tblaccount
+			Character tblcharacter
+		}
+		or DB_Tuple2[tblaccount, tblcharacter]	<- fields are T1, T2
+
+		DB_InnerJoin("Account.UserUUID", "Character.UserUUID")
+		<- FROM `tblaccount` AS `t0` INNER JOIN `tblcharacter` AS `t1` ON `t0`.`UserUUID` = `t1`.`UserUUID`
+*/
+type DB_JoinType string
+
+const (
+	DB_INNER_JOIN DB_JoinType = "INNER JOIN"
+	DB_LEFT_JOIN  DB_JoinType = "LEFT JOIN"
+)
+
+type DB_Join struct {
+	join_type DB_JoinType
+	on        [][2]string
+}
+
+func DB_InnerJoin(left_column string, right_column string) DB_Join {
+	return DB_Join{join_type: DB_INNER_JOIN, on: [][2]string{{left_column, right_column}}}
+}
+
+func DB_LeftJoin(left_column string, right_column string) DB_Join {
+	return DB_Join{join_type: DB_LEFT_JOIN, on: [][2]string{{left_column, right_column}}}
+}
+
+/*
+	Add one more ON column pair, ANDed.
+	ON 컬럼 쌍을 하나 더 추가, AND 로 연결.
+*/
+func (join DB_Join) On(left_column string, right_column string) DB_Join {
+	join.on = append(append([][2]string{}, join.on...), [2]string{left_column, right_column})
+	return join
+}
+
+type DB_Tuple2[A interface{}, B interface{}] struct {
+	T1 A
+	T2 B
+}
+
+type DB_Tuple3[A interface{}, B interface{}, C interface{}] struct {
+	T1 A
+	T2 B
+	T3 C
+}
+
+type db_JoinTable struct {
+	Index   []int
+	Name    string
+	Alias   string
+	TblType reflect.Type
+}
+
+/*
+	Tables of a join struct. Every exported field must be a named (not embedded) table struct.
+	join 구조체의 테이블들. export 된 모든 필드는 이름이 있는 (embedded 가 아닌) 테이블 구조체여야 함.
+*/
+func db_JoinTables(join_type reflect.Type) ([]db_JoinTable, bool) {
+	if join_type.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	var tables []db_JoinTable
+	for i := 0; i < join_type.NumField(); i++ {
+		t := join_type.Field(i)
+		if false == t.IsExported() {
+			continue
+		}
+		if true == t.Anonymous || t.Type.Kind() != reflect.Struct || true == db_IsValueStruct(t.Type) {
+			return nil, false
+		}
+
+		tables = append(tables, db_JoinTable{Index: t.Index, Name: t.Name, Alias: fmt.Sprint("t", len(tables)), TblType: t.Type})
+	}
+
+	return tables, 1 < len(tables)
+}
+
+func db_JoinFindColumn(tables []db_JoinTable, name string) (int, db_ColumnInfo, error) {
+	table_name, col_name, qualified := strings.Cut(name, ".")
+	if false == qualified {
+		col_name = name
+	}
+
+	found := -1
+	var found_col db_ColumnInfo
+	for i, tbl := range tables {
+		if true == qualified && tbl.Name != table_name {
+			continue
+		}
+		if col, ok := db_FindColumn(tbl.TblType, col_name); ok {
+			if found != -1 {
+				return -1, found_col, errors.New(fmt.Sprint("[ SQL ERROR ] Ambiguous column - ", name))
+			}
+			found = i
+			found_col = col
+		}
+	}
+
+	if found == -1 {
+		return -1, found_col, errors.New(fmt.Sprint("[ SQL ERROR ] Unknown column - ", name, " in JOIN"))
+	}
+
+	return found, found_col, nil
+}
+
+func db_JoinColumnResolver(d DB_Dialect, tables []db_JoinTable) db_ColumnResolver {
+	return func(name string) (string, error) {
+		i, col, err := db_JoinFindColumn(tables, name)
+		if err != nil {
+			return "", err
+		}
+
+		return d.Quote(tables[i].Alias) + "." + d.Quote(col.Name), nil
+	}
+}
+
+func db_Make_JOIN_Query(d DB_Dialect, tbl_columns interface{}, tbl_where interface{}, joins []DB_Join, conditions ...interface{}) (string, []interface{}, error) {
+
+	tbl_val := reflect.ValueOf(tbl_columns)
+	where_val := reflect.ValueOf(tbl_where)
+	tables, ok := db_JoinTables(tbl_val.Type())
+	if false == ok {
+		return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Not a JOIN struct - ", tbl_val.Type()))
+	}
+
+	if len(joins) != len(tables)-1 {
+		return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] JOIN needs one DB_Join per joined table - tables ", len(tables), ", joins ", len(joins)))
+	}
+
+	for _, tbl := range tables {
+		if err := db_CheckColumns(tbl.TblType); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var target_column []string
+	for _, tbl := range tables {
+		for _, col := range db_UsedColumns(tbl_val.FieldByIndex(tbl.Index)) {
+			target_column = append(target_column, d.Quote(tbl.Alias)+"."+d.Quote(col.Name))
+		}
+	}
+
+	/*
+		Each DB_Join compares the next table with one of the tables before it.
+		각 DB_Join 은 다음 테이블과 그 이전 테이블 중 하나를 비교.
+	*/
+	from_table := db_QuoteTable(d, db_TableName(tables[0].TblType)) + " AS " + d.Quote(tables[0].Alias)
+	for i, join := range joins {
+		next := i + 1
+		if join.join_type != DB_INNER_JOIN && join.join_type != DB_LEFT_JOIN {
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] Unknown JOIN type - ", join.join_type))
+		}
+		if 0 == len(join.on) {
+			return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] There is no ON column for ", tables[next].Name))
+		}
+
+		var on_elems []string
+		for _, pair := range join.on {
+			left, left_col, err := db_JoinFindColumn(tables, pair[0])
+			if err != nil {
+				return "", nil, err
+			}
+			right, right_col, err := db_JoinFindColumn(tables, pair[1])
+			if err != nil {
+				return "", nil, err
+			}
+			if left == next {
+				left, right = right, left
+				left_col, right_col = right_col, left_col
+			}
+			if right != next || left >= next {
+				return "", nil, errors.New(fmt.Sprint("[ SQL ERROR ] JOIN ON must compare ", tables[next].Name, " with a table before it - ", pair[0], " = ", pair[1]))
+			}
+			on_elems = append(on_elems, d.Quote(tables[left].Alias)+"."+d.Quote(left_col.Name)+" = "+d.Quote(tables[right].Alias)+"."+d.Quote(right_col.Name))
+		}
+
+		from_table += " " + string(join.join_type) + " " + db_QuoteTable(d, db_TableName(tables[next].TblType)) + " AS " + d.Quote(tables[next].Alias) + " ON " + strings.Join(on_elems, " AND ")
+	}
+
+	/*
+		The where struct works per table as in DB_SELECT.
+		where 구조체는 DB_SELECT 와 같이 테이블 별로 동작.
+	*/
+	var where_elems []string
+	var where_args []interface{}
+	for _, tbl := range tables {
+		elems, args := db_WhereColumns(d, where_val.FieldByIndex(tbl.Index), d.Quote(tbl.Alias)+".")
+		where_elems = append(where_elems, elems...)
+		where_args = append(where_args, args...)
+	}
+
+	column := db_JoinColumnResolver(d, tables)
+	opts, conditions := db_SplitOptions(conditions)
+
+	where_str, where_args, err := db_Make_WHERE_Conditions(d, where_elems, where_args, column, conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	option_str, option_args, err := opts.db_Build(d, column)
+	if err != nil {
+		logger.Error(err)
+		return "", nil, err
+	}
+	where_args = append(where_args, option_args...)
+
+	select_str := "SELECT "
+	if true == opts.distinct {
+		select_str += "DISTINCT "
+	}
+
+	queryStr := d.Rebind(select_str + strings.Join(target_column, ", ") + " FROM " + from_table + where_str + option_str + ";")
+
+	return queryStr, where_args, nil
+}
+
+/*
+	< How To Use >
+	ex)
+		type tblaccount struct {
+			PlayerKey   string
+			UserUUID    int64
+			ConnectIP   string
+			ConnectTime time.Time
+			CreateTime  time.Time
+			GameDBID    int
+		}
+
+		var tbl_select, tbl_where tblaccount
+		DB_InitTable(&tbl_select, &tbl_where)
+
+		tbl_select.PlayerKey = "Yea"			<- It doesn't matter what value you set
+		tbl_select.GameDBID = 9999				<- It doesn't matter what value you set
+
+		tbl_where.ConnectIP = "127.0.0.1"		<- Setting value is important in setting query conditions
+		tbl_where.GameDBID = 1					<- Setting value is important in setting query conditions
+		tbl_where.UserUUID = 10					<- Setting value is important in setting query conditions
+
+		DB_SELECT(db, &tbl_select, &tbl_where)	<- Sended Query : SELECT PlayerKey, GameDBID FROM tblaccount WHERE UserUUID = ? AND ConnectIP = ? AND GameDBID = ?
+												   Bound Args   : [10, "127.0.0.1", 1]
+
+		DB_SELECT(db, tbl_select, tblaccount{}, "ORDER BY UserUUID ASC Limit 10") <- Sended Query : SELECT PlayerKey, GameDBID FROM tblaccount ORDER BY UserUUID ASC Limit 10
+
+		DB_SELECT(db, tbl_select, tblaccount{}, DB_OrderBy("UserUUID", DB_ASC), DB_Limit(10)) <- Same query, with the column name checked before sending
+
+		DB_SELECT_Context(ctx, db, tbl_select, tbl_where)	<- Every DB_* function has a _Context variant, which stops at the ctx deadline / cancel
+*/
+func DB_SELECT[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_SELECT_Context(context.Background(), db, tbl_target, tbl_where, conditions...)
+}
+
+func DB_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+
+	logger := InitLogger()
+	var retValues []DB_Table
+
+	/*
+		Check that each table type is the same.
+		각 테이블 타입이 동일한지 체크.
+	*/
+	{
+		tb_col_t := reflect.TypeOf(&tbl_target)
+		tb_where_t := reflect.TypeOf(&tbl_where)
+
+		if tb_col_t.Name() != tb_where_t.Name() {
+			logger.Error(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+			return retValues, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+		}
+	}
+
+	/*
+		Save the source member variable index value to receive the value.
+		값을 받을 원본 멤버 변수 인덱스 값을 저장.
+	*/
+	var target_columns []db_ColumnInfo
+	tbl_val := reflect.ValueOf(&tbl_target).Elem()
+	for _, col := range db_Columns(tbl_val.Type()) {
+		if true == db_IsUse(tbl_val.FieldByIndex(col.Index)) {
+			target_columns = append(target_columns, col)
+		}
+	}
+
+	queryStr, args, err := db_Make_SELECT_Query(db_DialectOf(db), tbl_target, tbl_where, conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	logger.Info(queryStr, args)
+	rows, err := db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
+		return retValues, db_ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		/*
+			Set table member var address parameters to receive results.
+			결과 받을 테이블 주소 파라미터 셋팅.
+		*/
+		var obj DB_Table
+		DB_InitTable(&obj)
+		retT_val := reflect.ValueOf(&obj).Elem()
+		var target_ptr_list []interface{}
+		for _, col := range target_columns {
+			target_ptr_list = append(target_ptr_list, db_ScanDest(retT_val.FieldByIndex(col.Index), col.Field))
+		}
+
+		/*
+			Create result values sequentially on table objects.
+			테이블 객체에 순차적으로 결과 값 작성.
+		*/
+		err = rows.Scan(target_ptr_list...)
+		if err != nil {
+			logger.Error(err)
+			return retValues, err
+		}
+
+		retValues = append(retValues, obj)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error(err)
+		return retValues, db_ContextError(ctx, err)
+	}
+
+	return retValues, nil
+}
+
+func isValid_insert[DB_Table interface{}](tbl_insert ...DB_Table) (bool, error) {
+
+	if 1 > len(tbl_insert) {
+		logger.Error("[ SQL ERROR ] There is no data for INSERT")
+		return false, errors.New("[ SQL ERROR ] There is no data for INSERT")
+	}
+
+	if err := db_CheckColumns(reflect.TypeOf(tbl_insert[0])); err != nil {
+		return false, err
+	}
+
+	/*
+		Error handling if any of the table column values to be INSERT are abnormal.
+		INSERT 할 테이블 컬럼 값이 하나라도 비정상인 경우 에러처리.
+	*/
+	tbl_name := reflect.TypeOf(tbl_insert[0]).Name()
+
+	for _, tbl_in := range tbl_insert {
+		tbl_val := reflect.ValueOf(&tbl_in).Elem()
+
+		elemTbl_name := reflect.TypeOf(tbl_in).Name()
+		if tbl_name != elemTbl_name {
+			logger.Error("[ SQL ERROR ] Not Same tables elements in INSERT ( ", tbl_name, " <> ", elemTbl_name, " )")
+			return false, errors.New(fmt.Sprint("[ SQL ERROR ] Not Same tables elements in INSERT ( ", tbl_name, " <> ", elemTbl_name, " )"))
+		}
+
+		for _, col := range db_Columns(tbl_val.Type()) {
+			if true != db_IsNullColumn(col.Field) {
+				val := tbl_val.FieldByIndex(col.Index)
+				if true != db_IsUse(val) {
+					logger.Errorf("[ SQL ERROR ] Invalid table field value - %v", elemTbl_name)
+					return false, errors.New(fmt.Sprint("[ SQL ERROR ] Invalid table field value - ", elemTbl_name))
+				}
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func DB_INSERT[DB_Table interface{}](db DB_Executor, tbl_insert ...DB_Table) (int64, error) {
+	return DB_INSERT_Context(context.Background(), db, tbl_insert...)
+}
+
+func DB_INSERT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_insert ...DB_Table) (int64, error) {
+
+	_, err := isValid_insert(tbl_insert...)
+	if err != nil {
+		return 0, err
+	}
+
+	queryStr, args, err := db_Make_INSERT_Query(db_DialectOf(db), tbl_insert...)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
+		return 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	return affect, err
+}
+
+func DB_INSERT_AutoIncrease[DB_Table interface{}](db DB_Executor, tbl_insert ...DB_Table) (int64, int64, error) {
+	return DB_INSERT_AutoIncrease_Context(context.Background(), db, tbl_insert...)
+}
+
+func DB_INSERT_AutoIncrease_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_insert ...DB_Table) (int64, int64, error) {
+
+	_, err := isValid_insert(tbl_insert...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	d := db_DialectOf(db)
+	queryStr, args, err := db_Make_INSERT_Query(d, tbl_insert...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if false == d.SupportsLastInsertId() {
+		return db_INSERT_Returning(ctx, db, d, queryStr, args, tbl_insert...)
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
+		return 0, 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	lastInsertID, err := res.LastInsertId()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows LastInsertId error - %v", err)
+	}
+
+	return lastInsertID, affect, err
+}
+
+/*
+	For a dialect without LastInsertId (PostgreSQL), the auto increment column is read back with RETURNING.
+	The auto increment column is the PK:"true" column left unset in the INSERT.
+	LastInsertId 가 없는 방언 (PostgreSQL) 은 RETURNING 으로 자동 증가 컬럼 값을 다시 읽음.
+	자동 증가 컬럼은 INSERT 에서 설정하지 않은 PK:"true" 컬럼.
+*/
+func db_INSERT_Returning[DB_Table interface{}](ctx context.Context, db DB_Executor, d DB_Dialect, queryStr string, args []interface{}, tbl_insert ...DB_Table) (int64, int64, error) {
+
+	tbl_val := reflect.ValueOf(&tbl_insert[0]).Elem()
+	auto_column := db_AutoColumn(tbl_val)
+	if auto_column == "" {
+		logger.Error("[ SQL ERROR ] There is no unset PK column to RETURNING - ", tbl_val.Type().Name())
+		return 0, 0, errors.New(fmt.Sprint("[ SQL ERROR ] There is no unset PK column to RETURNING - ", tbl_val.Type().Name()))
+	}
+
+	return db_QueryReturning(ctx, db, d, queryStr, args, auto_column)
+}
+
+func db_AutoColumn(tbl_val reflect.Value) string {
+	for _, col := range db_Columns(tbl_val.Type()) {
+		_, thisIsPK := col.Field.Tag.Lookup("PK")
+		if true == thisIsPK && false == db_IsUse(tbl_val.FieldByIndex(col.Index)) {
+			return col.Name
+		}
+	}
+
+	return ""
+}
+
+/*
+	Runs the INSERT with RETURNING auto_column, and gives the first returned id and the row count.
+	INSERT 를 RETURNING auto_column 으로 실행하고, 처음 반환된 id 와 row 수를 돌려줌.
+*/
+func db_QueryReturning(ctx context.Context, db DB_Executor, d DB_Dialect, queryStr string, args []interface{}, auto_column string) (int64, int64, error) {
+	ids, err := db_QueryReturningIDs(ctx, db, d, queryStr, args, auto_column)
+	if err != nil || 0 == len(ids) {
+		return 0, int64(len(ids)), err
+	}
+
+	return ids[0], int64(len(ids)), nil
+}
+
+func db_QueryReturningIDs(ctx context.Context, db DB_Executor, d DB_Dialect, queryStr string, args []interface{}, auto_column string) ([]int64, error) {
+	queryStr = strings.TrimSuffix(queryStr, ";") + d.Returning([]string{d.Quote(auto_column)}) + ";"
+	logger.Info(queryStr, args)
+	rows, err := db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
+		return nil, db_ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func DB_UPDATE[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+	return DB_UPDATE_Context(context.Background(), db, tbl_target, tbl_where, conditions...)
+}
+
+func DB_UPDATE_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+
+	/*
+		Check that each table type is the same.
+		각 테이블 타입이 동일한지 체크.
+	*/
+	{
+		tb_col_t := reflect.TypeOf(&tbl_target)
+		tb_where_t := reflect.TypeOf(&tbl_where)
+
+		if tb_col_t.Name() != tb_where_t.Name() {
+			logger.Error("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name())
+			return 0, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+		}
+	}
+
+	queryStr, args, err := db_Make_UPDATE_Query(db_DialectOf(db), tbl_target, tbl_where, conditions...)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
+		return 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	return affect, err
+}
+
+func DB_DELETE[DB_Table interface{}](db DB_Executor, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+	return DB_DELETE_Context(context.Background(), db, tbl_where, conditions...)
+}
+
+func DB_DELETE_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+
+	queryStr, args, err := db_Make_DELETE_Query(db_DialectOf(db), tbl_where, conditions...)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		return 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	return affect, err
+}
+
+func DB_UPSERT[DB_Table interface{}](db DB_Executor, tbl_upsert DB_Table) (int64, error) {
+	return DB_UPSERT_Context(context.Background(), db, tbl_upsert)
+}
+
+func DB_UPSERT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_upsert DB_Table) (int64, error) {
+
+	queryStr, args, err := db_Make_UPSERT_Query(db_DialectOf(db), tbl_upsert)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		return 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	return affect, err
+}
+
+func DB_INCR[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) (int64, error) {
+	return DB_INCR_Context(context.Background(), db, tbl_target, tbl_where, size, conditions...)
+}
+
+func DB_INCR_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) (int64, error) {
+
+	/*
+		Check that each table type is the same.
+		각 테이블 타입이 동일한지 체크.
+	*/
+	{
+		tb_col_t := reflect.TypeOf(&tbl_target)
+		tb_where_t := reflect.TypeOf(&tbl_where)
+
+		if tb_col_t.Name() != tb_where_t.Name() {
+			logger.Error("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name())
+			return 0, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+		}
+	}
+
+	queryStr, args, err := db_Make_INCR_Query(db_DialectOf(db), tbl_target, tbl_where, size, conditions...)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
+		return 0, db_ContextError(ctx, err)
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	}
+
+	return affect, err
+}
+
+func DB_DECR[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) (int64, error) {
+	return DB_DECR_Context(context.Background(), db, tbl_target, tbl_where, size, conditions...)
+}
+
+func DB_DECR_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) (int64, error) {
+	return DB_INCR_Context(ctx, db, tbl_target, tbl_where, size*-1, conditions...)
+}
+
+func DB_INSERT_SELECT[DB_Table interface{}](db DB_Executor, tbl_insert DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_INSERT_SELECT_Context(context.Background(), db, tbl_insert, tbl_select, conditions...)
+}
+
+func DB_INSERT_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_insert DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+
+	/*
+		In the case of an auto-increment column, since it may be an empty column, we do not check that all columns have values.
+		자동 증가 컬럼의 경우, 비어있는 컬럼인 경우가 있으므로, 여기선 모든 컬럼에 값이 있는지 체크하지 않는다.
+	*/
+
+	var retValues []DB_Table
+	queryStr, args, err := db_Make_INSERT_Query(db_DialectOf(db), tbl_insert)
+	if err != nil {
+		return retValues, err
+	}
+
+	logger.Info(queryStr, args)
+	res, err := db.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
+		return retValues, db_ContextError(ctx, err)
+	}
+
+	_, err = res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+		return retValues, err
+	}
+
+	queryStr, args, err = db_Make_SELECT_Query(db_DialectOf(db), tbl_select, tbl_insert, conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	/*
+		Save the source member variable index value to receive the value.
+		값을 받을 원본 멤버 변수 인덱스 값을 저장.
+	*/
+	var target_columns []db_ColumnInfo
+	tbl_val := reflect.ValueOf(&tbl_select).Elem()
+	for _, col := range db_Columns(tbl_val.Type()) {
+		if true == db_IsUse(tbl_val.FieldByIndex(col.Index)) {
+			target_columns = append(target_columns, col)
+		}
+	}
+
+	logger.Info(queryStr, args)
+	rows, err := db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
+		return retValues, db_ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		/*
+			Set table member var address parameters to receive results.
+			결과 받을 테이블 주소 파라미터 셋팅.
+		*/
+		var obj DB_Table
+		DB_InitTable(&obj)
+		retT_val := reflect.ValueOf(&obj).Elem()
+		var target_ptr_list []interface{}
+		for _, col := range target_columns {
+			target_ptr_list = append(target_ptr_list, db_ScanDest(retT_val.FieldByIndex(col.Index), col.Field))
+		}
+
+		/*
+			Create result values sequentially on table objects.
+			테이블 객체에 순차적으로 결과 값 작성.
+		*/
+		err = rows.Scan(target_ptr_list...)
+		if err != nil {
+			return retValues, err
+		}
+
+		retValues = append(retValues, obj)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error(err)
+		return retValues, db_ContextError(ctx, err)
+	}
+
+	return retValues, nil
+}
+
+/*
+	< Write and SELECT >
+	DB_UPDATE_SELECT / DB_INCR_SELECT / DB_DECR_SELECT split the conditions.
+	DB_Cond conditions are for the write, so they can guard it. (ex. DB_Ge("Gold", 100) with DB_DECR_SELECT)
+	SELECT options (DB_OrderBy, DB_Limit, DB_ForUpdate ...) and raw strings are for the SELECT.
+	The SELECT reads the rows of tbl_where after the write, so its rows may include ones the guard did not change.
+	DB_UPDATE_SELECT / DB_INCR_SELECT / DB_DECR_SELECT 는 조건을 나눔.
+	DB_Cond 조건은 쓰기에 적용되어, 쓰기를 제한할 수 있음. (ex. DB_DECR_SELECT 와 DB_Ge("Gold", 100))
+	SELECT 옵션 (DB_OrderBy, DB_Limit, DB_ForUpdate ...) 과 raw 문자열은 SELECT 에 적용.
+	SELECT 는 쓰기 후 tbl_where 의 row 들을 읽으므로, 조건 때문에 변경되지 않은 row 도 포함될 수 있음.
+*/
+func DB_UPDATE_SELECT[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_UPDATE_SELECT_Context(context.Background(), db, tbl_target, tbl_where, tbl_select, conditions...)
+}
+
+func DB_UPDATE_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+
+	var retValues []DB_Table
+	write_conditions, select_conditions := db_SplitWriteConditions(conditions)
+	_, err := DB_UPDATE_Context(ctx, db, tbl_target, tbl_where, write_conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	return DB_SELECT_Context(ctx, db, tbl_select, tbl_where, select_conditions...)
+}
+
+func DB_UPSERT_SELECT[DB_Table interface{}](db DB_Executor, tbl_upsert DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_UPSERT_SELECT_Context(context.Background(), db, tbl_upsert, tbl_select, conditions...)
+}
+
+func DB_UPSERT_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_upsert DB_Table, tbl_select DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+
+	var retValues []DB_Table
+	_, err := DB_UPSERT_Context(ctx, db, tbl_upsert)
+	if err != nil {
+		return retValues, err
+	}
+
+	return DB_SELECT_Context(ctx, db, tbl_select, tbl_upsert, conditions...)
+}
+
+func DB_INCR_SELECT[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_INCR_SELECT_Context(context.Background(), db, tbl_target, tbl_where, size, conditions...)
+}
+
+func DB_INCR_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) ([]DB_Table, error) {
+
+	var retValues []DB_Table
+	write_conditions, select_conditions := db_SplitWriteConditions(conditions)
+	_, err := DB_INCR_Context(ctx, db, tbl_target, tbl_where, size, write_conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	return DB_SELECT_Context(ctx, db, tbl_target, tbl_where, select_conditions...)
+}
+
+func DB_DECR_SELECT[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_DECR_SELECT_Context(context.Background(), db, tbl_target, tbl_where, size, conditions...)
+}
+
+func DB_DECR_SELECT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, size int64, conditions ...interface{}) ([]DB_Table, error) {
+
+	var retValues []DB_Table
+	write_conditions, select_conditions := db_SplitWriteConditions(conditions)
+	_, err := DB_DECR_Context(ctx, db, tbl_target, tbl_where, size, write_conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	return DB_SELECT_Context(ctx, db, tbl_target, tbl_where, select_conditions...)
+}
+
+func db_SplitWriteConditions(conditions []interface{}) ([]interface{}, []interface{}) {
+	var write_conditions, select_conditions []interface{}
+	for _, condition := range conditions {
+		if _, ok := condition.(DB_Cond); ok {
+			write_conditions = append(write_conditions, condition)
+		} else {
+			select_conditions = append(select_conditions, condition)
+		}
+	}
+
+	return write_conditions, select_conditions
+}
+
+/*
+	< How To Use >
+	Aggregates use the same tbl_where as DB_SELECT. tbl_target marks the columns to aggregate,
+	tbl_group marks the GROUP BY columns, and both are filled in each result row.
+	A SUM / MAX / MIN of no rows is NULL, then the column is left as DB_InitTable made it.
+	DB_SELECT 와 같은 tbl_where 를 사용. tbl_target 은 집계할 컬럼, tbl_group 은 GROUP BY 컬럼을 표시하며,
+	결과 row 마다 두 컬럼 모두 채워짐.
+	row 가 없는 SUM / MAX / MIN 은 NULL 이며, 이 때 컬럼은 DB_InitTable 상태로 남음.
+
+	ex)
+		var tbl_target, tbl_group, tbl_where tblaccount
+		DB_InitTable(&tbl_target, &tbl_group, &tbl_where)
+		tbl_where.GameDBID = 3
+
+		count, err := DB_COUNT(db, tbl_where)
+		<- SELECT COUNT(*) FROM `tblaccount` WHERE `GameDBID` = ?;
+
+		tbl_target.UserUUID = 0
+		tbl_max, err := DB_MAX(db, tbl_target, tbl_where)		<- tbl_max.UserUUID
+		<- SELECT MAX(`UserUUID`) FROM `tblaccount` WHERE `GameDBID` = ?;
+
+		tbl_group.ConnectIP = ""
+		groups, err := DB_COUNT_GROUP(db, tbl_group, tbl_where, DB_Having(DB_Expr("COUNT(*) > ?", 1)))	<- groups[i].Group.ConnectIP, groups[i].Count
+		<- SELECT `ConnectIP`, COUNT(*) FROM `tblaccount` WHERE `GameDBID` = ? GROUP BY `ConnectIP` HAVING COUNT(*) > ?;
+*/
+type DB_GroupCount[DB_Table interface{}] struct {
+	Group DB_Table
+	Count int64
+}
+
+func DB_COUNT[DB_Table interface{}](db DB_Executor, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+	return DB_COUNT_Context(context.Background(), db, tbl_where, conditions...)
+}
+
+func DB_COUNT_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_where DB_Table, conditions ...interface{}) (int64, error) {
+
+	var tbl_none DB_Table
+	DB_InitTable(&tbl_none)
+
+	_, counts, err := db_AGGREGATE(ctx, db, "COUNT", tbl_none, tbl_none, tbl_where, conditions...)
+	if err != nil || 0 == len(counts) {
+		return 0, err
+	}
+
+	return counts[0], nil
+}
+
+func DB_COUNT_GROUP[DB_Table interface{}](db DB_Executor, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_GroupCount[DB_Table], error) {
+	return DB_COUNT_GROUP_Context(context.Background(), db, tbl_group, tbl_where, conditions...)
+}
+
+func DB_COUNT_GROUP_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_GroupCount[DB_Table], error) {
+
+	var retValues []DB_GroupCount[DB_Table]
+	if 0 == len(db_UsedColumns(reflect.ValueOf(tbl_group))) {
+		logger.Error("[ SQL ERROR ] There is no GROUP BY column")
+		return retValues, errors.New("[ SQL ERROR ] There is no GROUP BY column")
+	}
+
+	groups, counts, err := db_AGGREGATE(ctx, db, "COUNT", tbl_group, tbl_group, tbl_where, conditions...)
+	if err != nil {
+		return retValues, err
+	}
+
+	for i := range groups {
+		retValues = append(retValues, DB_GroupCount[DB_Table]{Group: groups[i], Count: counts[i]})
+	}
+
+	return retValues, nil
+}
+
+func DB_SUM[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return DB_SUM_Context(context.Background(), db, tbl_target, tbl_where, conditions...)
+}
+
+func DB_SUM_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return db_AGGREGATE_One(ctx, db, "SUM", tbl_target, tbl_where, conditions...)
+}
+
+func DB_MAX[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return DB_MAX_Context(context.Background(), db, tbl_target, tbl_where, conditions...)
+}
+
+func DB_MAX_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return db_AGGREGATE_One(ctx, db, "MAX", tbl_target, tbl_where, conditions...)
+}
+
+func DB_MIN[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return DB_MIN_Context(context.Background(), db, tbl_target, tbl_where, conditions...)
+}
+
+func DB_MIN_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+	return db_AGGREGATE_One(ctx, db, "MIN", tbl_target, tbl_where, conditions...)
+}
+
+func DB_SUM_GROUP[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_SUM_GROUP_Context(context.Background(), db, tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func DB_SUM_GROUP_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return db_AGGREGATE_Group(ctx, db, "SUM", tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func DB_MAX_GROUP[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_MAX_GROUP_Context(context.Background(), db, tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func DB_MAX_GROUP_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return db_AGGREGATE_Group(ctx, db, "MAX", tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func DB_MIN_GROUP[DB_Table interface{}](db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return DB_MIN_GROUP_Context(context.Background(), db, tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func DB_MIN_GROUP_Context[DB_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+	return db_AGGREGATE_Group(ctx, db, "MIN", tbl_target, tbl_group, tbl_where, conditions...)
+}
+
+func db_AGGREGATE_One[DB_Table interface{}](ctx context.Context, db DB_Executor, fn string, tbl_target DB_Table, tbl_where DB_Table, conditions ...interface{}) (DB_Table, error) {
+
+	var tbl_none DB_Table
+	DB_InitTable(&tbl_none)
+
+	rows, _, err := db_AGGREGATE(ctx, db, fn, tbl_target, tbl_none, tbl_where, conditions...)
+	if err != nil || 0 == len(rows) {
+		return tbl_none, err
+	}
+
+	return rows[0], nil
+}
+
+func db_AGGREGATE_Group[DB_Table interface{}](ctx context.Context, db DB_Executor, fn string, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, error) {
+
+	if 0 == len(db_UsedColumns(reflect.ValueOf(tbl_group))) {
+		logger.Error("[ SQL ERROR ] There is no GROUP BY column")
+		return nil, errors.New("[ SQL ERROR ] There is no GROUP BY column")
+	}
+
+	rows, _, err := db_AGGREGATE(ctx, db, fn, tbl_target, tbl_group, tbl_where, conditions...)
+	return rows, err
+}
+
+/*
+	Each result row holds the group columns and the aggregated columns of tbl_target, or the row count for COUNT.
+	각 결과 row 는 group 컬럼과 tbl_target 의 집계 컬럼을 가지며, COUNT 는 row 수를 가짐.
+*/
+func db_AGGREGATE[DB_Table interface{}](ctx context.Context, db DB_Executor, fn string, tbl_target DB_Table, tbl_group DB_Table, tbl_where DB_Table, conditions ...interface{}) ([]DB_Table, []int64, error) {
+
+	logger := InitLogger()
+	var retValues []DB_Table
+	var retCounts []int64
+
+	group_columns := db_UsedColumns(reflect.ValueOf(tbl_group))
+	var target_columns []db_ColumnInfo
+	if fn != "COUNT" {
+		target_columns = db_UsedColumns(reflect.ValueOf(tbl_target))
+	}
+
+	queryStr, args, err := db_Make_AGGREGATE_Query(db_DialectOf(db), fn, tbl_target, tbl_group, tbl_where, conditions...)
+	if err != nil {
+		logger.Error(err)
+		return retValues, retCounts, err
+	}
+
+	logger.Info(queryStr, args)
+	rows, err := db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
+		return retValues, retCounts, db_ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var obj DB_Table
+		var count int64
+		DB_InitTable(&obj)
+		retT_val := reflect.ValueOf(&obj).Elem()
+		var target_ptr_list []interface{}
+		for _, col := range group_columns {
+			target_ptr_list = append(target_ptr_list, db_ScanDest(retT_val.FieldByIndex(col.Index), col.Field))
+		}
+		for _, col := range target_columns {
+			target_ptr_list = append(target_ptr_list, db_ScanNullableDest(retT_val.FieldByIndex(col.Index)))
+		}
+		if fn == "COUNT" {
+			target_ptr_list = append(target_ptr_list, &count)
+		}
+
+		err = rows.Scan(target_ptr_list...)
+		if err != nil {
+			logger.Error(err)
+			return retValues, retCounts, err
+		}
+
+		retValues = append(retValues, obj)
+		retCounts = append(retCounts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error(err)
+		return retValues, retCounts, err
+	}
+
+	return retValues, retCounts, nil
+}
+
+/*
+	< How To Use >
+	ex)
+		var tbl_select, tbl_where DB_Tuple2[tblaccount, tblcharacter]
+		DB_InitTable(&tbl_select, &tbl_where)		<- each table of the join struct is initialized
+
+		tbl_select.T1.PlayerKey = ""
+		tbl_select.T2.CharName = ""
+		tbl_where.T1.GameDBID = 3
+
+		rows, err := DB_SELECT_JOIN(db, tbl_select, tbl_where, []DB_Join{DB_LeftJoin("T1.UserUUID", "T2.UserUUID")}, DB_OrderBy("T2.Level", DB_DESC))
+		<- SELECT `t0`.`PlayerKey`, `t1`.`CharName` FROM `tblaccount` AS `t0` LEFT JOIN `tblcharacter` AS `t1` ON `t0`.`UserUUID` = `t1`.`UserUUID`
+		   WHERE `t0`.`GameDBID` = ? ORDER BY `t1`.`Level` DESC;
+
+	Columns of a LEFT JOINed table with no matching row are left as DB_InitTable made them.
+	LEFT JOIN 된 테이블에 매칭되는 row 가 없으면, 그 컬럼은 DB_InitTable 상태로 남음.
+*/
+func DB_SELECT_JOIN[DB_Join_Table interface{}](db DB_Executor, tbl_target DB_Join_Table, tbl_where DB_Join_Table, joins []DB_Join, conditions ...interface{}) ([]DB_Join_Table, error) {
+	return DB_SELECT_JOIN_Context(context.Background(), db, tbl_target, tbl_where, joins, conditions...)
+}
+
+func DB_SELECT_JOIN_Context[DB_Join_Table interface{}](ctx context.Context, db DB_Executor, tbl_target DB_Join_Table, tbl_where DB_Join_Table, joins []DB_Join, conditions ...interface{}) ([]DB_Join_Table, error) {
+
+	logger := InitLogger()
+	var retValues []DB_Join_Table
+
+	queryStr, args, err := db_Make_JOIN_Query(db_DialectOf(db), tbl_target, tbl_where, joins, conditions...)
+	if err != nil {
+		logger.Error(err)
+		return retValues, err
+	}
+
+	tables, _ := db_JoinTables(reflect.TypeOf(tbl_target))
+	tbl_val := reflect.ValueOf(tbl_target)
+	target_columns := make([][]db_ColumnInfo, len(tables))
+	for i, tbl := range tables {
+		target_columns[i] = db_UsedColumns(tbl_val.FieldByIndex(tbl.Index))
+	}
+
+	logger.Info(queryStr, args)
+	rows, err := db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
+		return retValues, db_ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var obj DB_Join_Table
+		DB_InitTable(&obj)
+		retT_val := reflect.ValueOf(&obj).Elem()
+		var target_ptr_list []interface{}
+		for i, tbl := range tables {
+			left_joined := 0 < i && joins[i-1].join_type == DB_LEFT_JOIN
+			tbl_obj := retT_val.FieldByIndex(tbl.Index)
+			for _, col := range target_columns[i] {
+				if true == left_joined {
+					target_ptr_list = append(target_ptr_list, db_ScanNullableDest(tbl_obj.FieldByIndex(col.Index)))
+				} else {
+					target_ptr_list = append(target_ptr_list, db_ScanDest(tbl_obj.FieldByIndex(col.Index), col.Field))
+				}
+			}
+		}
+
+		err = rows.Scan(target_ptr_list...)
+		if err != nil {
+			logger.Error(err)
+			return retValues, err
+		}
+
+		retValues = append(retValues, obj)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error(err)
+		return retValues, err
+	}
+
+	return retValues, nil
+}
+
+/*
+	The SQL of each job is built when Run is called, so it follows the dialect of the executor given to Run.
+	ADD_* copies its slices and builds the SQL once to check it, so an invalid job fails at ADD_*.
+	각 job 의 SQL 은 Run 호출 시점에 생성되므로, Run 에 주어진 executor 의 방언을 따름.
+	ADD_* 는 slice 들을 복사하고 SQL 을 한 번 생성해 확인하므로, 잘못된 job 은 ADD_* 에서 실패함.
+*/
+type dbJobQuery struct {
+	kind        string
+	table       string
+	auto_column string
+	savepoint   string
+	try         bool
+	expect      *DB_Expect
+	deferred    func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error
+	read        func(ctx context.Context, tx DB_Executor) (int64, error)
+	redis       *dbJobRedis
+	build       func(d DB_Dialect) (string, []interface{}, error)
+	insert      func(d DB_Dialect) (string, [][]interface{}, error)
+}
+
+type DBJob struct {
+	queryList  []dbJobQuery
+	jobCounter int
+	errorMap   map[int]error
+	txOptions  sql.TxOptions
+	retry      DB_RetryPolicy
+	coalesce   *DB_CoalescePolicy
+	dry        *DB_DryRun
+	dialect    DB_Dialect
+}
+
+func (dbjob *DBJob) readyNextProcess(err error) {
+	dbjob.jobCounter += 1
+	if err != nil {
+		if dbjob.errorMap == nil {
+			dbjob.errorMap = make(map[int]error)
+		}
+		dbjob.errorMap[dbjob.jobCounter] = err
+	}
+}
+
+/*
+	Adds the job after building its SQL once with the dialect of SetDialect, so an invalid job is returned by ADD_* and not by Run.
+	The SQL is built again in Run, for the dialect of the executor.
+	SetDialect 의 방언으로 SQL 을 한 번 생성해 본 뒤 job 을 추가하므로, 잘못된 job 은 Run 이 아닌 ADD_* 에서 반환됨.
+	SQL 은 Run 에서 executor 의 방언으로 다시 생성됨.
+*/
+func (dbjob *DBJob) addQuery(job dbJobQuery) error {
+	d := dbjob.dialect
+	if d == nil {
+		d = DB_DefaultDialect
+	}
+
+	if _, _, err := job.build(d); err != nil {
+		return err
+	}
+
+	dbjob.queryList = append(dbjob.queryList, job)
+	return nil
+}
+
+/*
+	Dialect ADD_* checks the SQL of a job with, call it before ADD_*. (default: DB_DefaultDialect)
+	Set it to the dialect of the executor given to Run, so a job the executor can not run (ex. DB_SkipLocked on SQLite) fails at ADD_*.
+	ADD_* 가 job 의 SQL 을 확인할 방언, ADD_* 전에 호출. (기본: DB_DefaultDialect)
+	Run 에 줄 executor 의 방언으로 지정하면, executor 가 실행할 수 없는 job (ex. SQLite 의 DB_SkipLocked) 은 ADD_* 에서 실패함.
+*/
+func (dbjob *DBJob) SetDialect(d DB_Dialect) {
+	dbjob.dialect = d
+}
+
+/*
+	Isolation level and read only mode of the transaction Run opens. (default: the database default, read write)
+	Run 이 여는 트랜잭션의 격리 수준과 읽기 전용 모드. (기본: DB 기본값, 읽기 쓰기)
+*/
+func (dbjob *DBJob) SetIsolation(level sql.IsolationLevel) {
+	dbjob.txOptions.Isolation = level
+}
+
+func (dbjob *DBJob) SetReadOnly(read_only bool) {
+	dbjob.txOptions.ReadOnly = read_only
+}
+
+/*
+	< Retry >
+	On a deadlock / lock wait timeout, Run rolls back and re-runs the whole job list in a fresh transaction, up to MaxRetry times.
+	The wait before the n-th retry is BaseDelay * 2^(n-1), at most MaxDelay, and a random jitter takes off up to half of it.
+	OnRetry is called before each wait. (ex. count a metric)
+	deadlock / lock wait timeout 이 나면, Run 은 롤백하고 새 트랜잭션에서 job 목록 전체를 최대 MaxRetry 번 다시 실행.
+	n 번째 재시도 전 대기는 BaseDelay * 2^(n-1), 최대 MaxDelay 이며, 임의의 jitter 로 최대 절반까지 줄어듦.
+	OnRetry 는 매 대기 전에 호출됨. (ex. 메트릭 집계)
+
+	ex)
+		dbjob.SetRetry(DB_RetryPolicy{MaxRetry: 3, BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second,
+			OnRetry: func(attempt int, delay time.Duration, err error) { metric.DBJobRetry.Inc() }})
+*/
+type DB_RetryPolicy struct {
+	MaxRetry  int
+	BaseDelay time.Duration // default 10ms. 기본 10ms.
+	MaxDelay  time.Duration // default 1s. 기본 1s.
+	OnRetry   func(attempt int, delay time.Duration, err error)
+}
+
+func (dbjob *DBJob) SetRetry(policy DB_RetryPolicy) {
+	dbjob.retry = policy
+}
+
+func (policy DB_RetryPolicy) delay(attempt int) time.Duration {
+	base := policy.BaseDelay
+	if base <= 0 {
+		base = 10 * time.Millisecond
+	}
+	max_delay := policy.MaxDelay
+	if max_delay <= 0 {
+		max_delay = time.Second
+	}
+
+	delay := max_delay
+	if attempt < 32 && base < max_delay>>uint(attempt-1) {
+		delay = base << uint(attempt-1)
+	}
+
+	return delay - time.Duration(rand.Int64N(int64(delay/2)+1))
+}
+
+/*
+	< Coalesce >
+	Consecutive ADD_INSERT jobs to the same table and column set are run as multi-row INSERTs.
+	A statement is cut before it has more than MaxPlaceholders placeholders or MaxPacketBytes bytes. (the size of the args is estimated)
+	Each job still gets its own Affected and LastInsertId. SQL of a job is the statement which has its first row.
+	연속된 같은 테이블 / 같은 컬럼 구성의 ADD_INSERT job 들을 multi-row INSERT 로 실행.
+	한 구문은 placeholder 가 MaxPlaceholders 개, 크기가 MaxPacketBytes 바이트를 넘기 전에 나뉨. (인자의 크기는 추정치)
+	각 job 은 여전히 자신의 Affected 와 LastInsertId 를 가짐. job 의 SQL 은 그 첫 row 가 들어있는 구문.
+
+	< 주의점 >
+	1. LastInsertId of each job assumes the ids of one statement are consecutive. (MySQL innodb_autoinc_lock_mode 2 with concurrent inserts breaks it)
+	2. MaxPacketBytes should be under max_allowed_packet of MySQL.
+	3. a statement which affected other than its row count fails Run. (ex. a trigger skipping rows)
+
+	ex)
+		dbjob.SetCoalesce(DB_CoalescePolicy{})	<- default limits
+		for _, item := range items {
+			ADD_INSERT(&dbjob, item)
+		}
+*/
+type DB_CoalescePolicy struct {
+	MaxPlaceholders int // default 65535, 999 on SQLite. 기본 65535, SQLite 는 999.
+	MaxPacketBytes  int // default 4MB. 기본 4MB.
+}
+
+func (dbjob *DBJob) SetCoalesce(policy DB_CoalescePolicy) {
+	dbjob.coalesce = &policy
+}
+
+func (policy DB_CoalescePolicy) limits(d DB_Dialect) (int, int) {
+	max_placeholders := policy.MaxPlaceholders
+	if max_placeholders <= 0 {
+		max_placeholders = 65535
+		if d.Name() == "sqlite" {
+			max_placeholders = 999
+		}
+	}
+	max_bytes := policy.MaxPacketBytes
+	if max_bytes <= 0 {
+		max_bytes = 4 << 20
+	}
+
+	return max_placeholders, max_bytes
+}
+
+/*
+	< Expect >
+	Affected row count a job must have, given with the conditions of ADD_UPDATE / ADD_DELETE / ADD_INCR / ADD_DECR. (read row count of ADD_SELECT)
+	When it is not met, Run rolls back every job and returns DB_ExpectError with the job index.
+	MySQL counts only the rows actually changed by UPDATE, unless the DSN has clientFoundRows=true.
+	job 이 가져야 하는 affected row 수로, ADD_UPDATE / ADD_DELETE / ADD_INCR / ADD_DECR 의 조건과 함께 넘김. (ADD_SELECT 는 읽은 row 수)
+	충족하지 못하면 Run 은 모든 job 을 롤백하고 job 인덱스가 담긴 DB_ExpectError 를 반환.
+	MySQL 은 DSN 에 clientFoundRows=true 가 없으면, UPDATE 로 실제 변경된 row 만 셈.
+
+	ex)
+		ADD_DECR(&dbjob, tbl_gold, tbl_where, 100, DB_Ge("Gold", 100), DB_ExpectExactly(1))	<- rolls back when gold is not enough
+*/
+type DB_Expect struct {
+	at_least bool
+	rows     int64
+}
+
+func DB_ExpectExactly(rows int64) DB_Expect { return DB_Expect{rows: rows} }
+func DB_ExpectAtLeast(rows int64) DB_Expect { return DB_Expect{at_least: true, rows: rows} }
+func DB_ExpectNonZero() DB_Expect           { return DB_Expect{at_least: true, rows: 1} }
+
+func (e DB_Expect) check(affected int64) bool {
+	if true == e.at_least {
+		return affected >= e.rows
+	}
+	return affected == e.rows
+}
+
+func (e DB_Expect) String() string {
+	if true == e.at_least {
+		return fmt.Sprint("at least ", e.rows)
+	}
+	return fmt.Sprint("exactly ", e.rows)
+}
+
+type DB_ExpectError struct {
+	Index    int // job index, from 0. job 인덱스, 0 부터.
+	Kind     string
+	Expect   DB_Expect
+	Affected int64
+}
+
+func (e *DB_ExpectError) Error() string {
+	return fmt.Sprintf("[ DBJob Error ] Job index : %v (%v) - expected %v rows, but %v", e.Index, e.Kind, e.Expect, e.Affected)
+}
+
+/*
+	Takes DB_Expect out of the conditions. The last one wins.
+	The rest is a new slice, so the caller can reuse its slice after ADD_*.
+	조건에서 DB_Expect 를 꺼냄. 마지막 것이 적용됨.
+	나머지는 새 slice 이므로, 호출자는 ADD_* 후에 자신의 slice 를 재사용할 수 있음.
+*/
+func db_SplitExpect(conditions []interface{}) ([]interface{}, *DB_Expect) {
+	var expect *DB_Expect = nil
+	var rest []interface{}
+	for _, condition := range conditions {
+		if e, ok := condition.(DB_Expect); ok {
+			expect = &e
+			continue
+		}
+		rest = append(rest, condition)
+	}
+
+	return rest, expect
+}
+
+func ADD_INSERT[DB_TABLE interface{}](dbjob *DBJob, tbl_insert ...DB_TABLE) error {
+	var err error = nil
+
+	for {
+		if 1 > len(tbl_insert) {
+			logger.Error("[ DBJob Error ] AddJob - no Job added")
+			err = errors.New("[ DBJob Error ] AddJob - no Job added")
+			break
+		}
+
+		tbl_name := reflect.TypeOf(tbl_insert[0]).Name()
+
+		for _, tbl_in := range tbl_insert {
+			elemTbl_name := reflect.TypeOf(tbl_in).Name()
+			if tbl_name != elemTbl_name {
+				logger.Error("[ DBJob Error ] AddJob - Insert Job's element table name is not same")
+				err = errors.New("[ DBJob Error ] AddJob - Insert Job's element table name is not same")
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+
+		tbl_insert = append([]DB_TABLE(nil), tbl_insert...)
+		err = dbjob.addQuery(dbJobQuery{
+			kind:        "INSERT",
+			table:       db_TableName(reflect.TypeOf(tbl_insert[0])),
+			auto_column: db_AutoColumn(reflect.ValueOf(tbl_insert[0])),
+			build: func(d DB_Dialect) (string, []interface{}, error) {
+				return db_Make_INSERT_Query(d, tbl_insert...)
+			},
+			insert: func(d DB_Dialect) (string, [][]interface{}, error) {
+				return db_Make_INSERT_Rows(d, tbl_insert...)
+			},
+		})
+		break
+	}
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func ADD_UPDATE[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, conditions ...interface{}) error {
+	var err error = nil
+	conditions, expect := db_SplitExpect(conditions)
+
+	err = dbjob.addQuery(dbJobQuery{
+		kind:   "UPDATE",
+		table:  db_TableName(reflect.TypeOf(tbl_target)),
+		expect: expect,
+		build: func(d DB_Dialect) (string, []interface{}, error) {
+			return db_Make_UPDATE_Query(d, tbl_target, tbl_where, conditions...)
+		},
+	})
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func ADD_UPSERT[DB_TABLE interface{}](dbjob *DBJob, tbl_upsert DB_TABLE) error {
+	var err error = nil
+
+	err = dbjob.addQuery(dbJobQuery{
+		kind:  "UPSERT",
+		table: db_TableName(reflect.TypeOf(tbl_upsert)),
+		build: func(d DB_Dialect) (string, []interface{}, error) {
+			return db_Make_UPSERT_Query(d, tbl_upsert)
+		},
+	})
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func ADD_DELETE[DB_TABLE interface{}](dbjob *DBJob, tbl_where DB_TABLE, conditions ...interface{}) error {
+	var err error = nil
+	conditions, expect := db_SplitExpect(conditions)
+
+	err = dbjob.addQuery(dbJobQuery{
+		kind:   "DELETE",
+		table:  db_TableName(reflect.TypeOf(tbl_where)),
+		expect: expect,
+		build: func(d DB_Dialect) (string, []interface{}, error) {
+			return db_Make_DELETE_Query(d, tbl_where, conditions...)
+		},
+	})
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func ADD_INCR[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, size int64, conditions ...interface{}) error {
+	var err error = nil
+	conditions, expect := db_SplitExpect(conditions)
+
+	err = dbjob.addQuery(dbJobQuery{
+		kind:   "INCR",
+		table:  db_TableName(reflect.TypeOf(tbl_target)),
+		expect: expect,
+		build: func(d DB_Dialect) (string, []interface{}, error) {
+			return db_Make_INCR_Query(d, tbl_target, tbl_where, size, conditions...)
+		},
+	})
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func ADD_DECR[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, size int64, conditions ...interface{}) error {
+	var err error = nil
+	conditions, expect := db_SplitExpect(conditions)
+
+	err = dbjob.addQuery(dbJobQuery{
+		kind:   "DECR",
+		table:  db_TableName(reflect.TypeOf(tbl_target)),
+		expect: expect,
+		build: func(d DB_Dialect) (string, []interface{}, error) {
+			return db_Make_INCR_Query(d, tbl_target, tbl_where, -1*size, conditions...)
+		},
+	})
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+/*
+	Reads rows inside the transaction into dest while Run goes on, so the jobs after it can use them. (ex. with ADD_DEFER)
+	Use DB_ForUpdate / DB_ForShare (+ DB_SkipLocked / DB_NoWait) to lock the rows, and DB_ExpectNonZero to abort when nothing was read.
+	dest is valid only when Run succeeds.
+	Run 도중 트랜잭션 안에서 row 를 dest 로 읽어, 뒤의 job 들이 사용할 수 있게 함. (ex. ADD_DEFER 와 함께)
+	row 를 잠그려면 DB_ForUpdate / DB_ForShare (+ DB_SkipLocked / DB_NoWait), 읽은 것이 없을 때 중단하려면 DB_ExpectNonZero 를 사용.
+	dest 는 Run 이 성공했을 때만 유효.
+
+	ex)
+		var items []tblitem
+		ADD_SELECT(&dbjob, &items, tbl_select, tbl_where, DB_ForUpdate(), DB_ExpectNonZero())
+		ADD_DEFER(&dbjob, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error {
+			if 0 == len(items) {
+				return nil	<- dbjob.Preview reads no rows
+			}
+			tbl_target.Count = items[0].Count - 1
+			return ADD_UPDATE(deferred, tbl_target, tbl_where)
+		})
+*/
+func ADD_SELECT[DB_TABLE interface{}](dbjob *DBJob, dest *[]DB_TABLE, tbl_target DB_TABLE, tbl_where DB_TABLE, conditions ...interface{}) error {
+	var err error = nil
+	conditions, expect := db_SplitExpect(conditions)
+
+	if dest == nil {
+		logger.Error("[ DBJob Error ] AddJob - ADD_SELECT without dest")
+		err = errors.New("[ DBJob Error ] AddJob - ADD_SELECT without dest")
+	} else {
+		err = dbjob.addQuery(dbJobQuery{
+			kind:   "SELECT",
+			table:  db_TableName(reflect.TypeOf(tbl_target)),
+			expect: expect,
+			read: func(ctx context.Context, tx DB_Executor) (int64, error) {
+				rows, err := DB_SELECT_Context(ctx, tx, tbl_target, tbl_where, conditions...)
+				if err != nil {
+					return 0, err
+				}
+				*dest = rows
+				return int64(len(rows)), nil
+			},
+			build: func(d DB_Dialect) (string, []interface{}, error) {
+				return db_Make_SELECT_Query(d, tbl_target, tbl_where, conditions...)
+			},
+		})
+	}
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+/*
+	< Redis >
+	A Redis command run after the SQL transaction is committed, in the order it was added. (like AddJob_REDIS of DBJob.ts)
+	Redis can not roll back, so each job may register a compensating command.
+	A Redis job in an optional group rolled back to its savepoint does not run.
+	When a Redis job fails, the compensations of the Redis jobs done before it run in reverse order, and Run returns the error.
+	The committed SQL jobs stay, so the compensations must undo what the Redis jobs did.
+	The arguments are checked at ADD_REDIS: string, []byte, bool, every integer and float kind, or a fmt.Stringer.
+	SQL 트랜잭션이 commit 된 후 추가된 순서대로 실행되는 Redis 명령. (DBJob.ts 의 AddJob_REDIS 와 같음)
+	Redis 는 롤백할 수 없으므로, 각 job 은 보상 명령을 등록할 수 있음.
+	savepoint 로 롤백된 선택적 그룹 안의 Redis job 은 실행하지 않음.
+	Redis job 이 실패하면, 그 전에 실행된 Redis job 들의 보상 명령을 역순으로 실행하고, Run 은 에러를 반환.
+	commit 된 SQL job 은 그대로 남으므로, 보상 명령은 Redis job 이 한 일을 되돌려야 함.
+	인자는 ADD_REDIS 에서 확인함: string, []byte, bool, 모든 정수와 실수 kind, 또는 fmt.Stringer.
+
+	ex)
+		ADD_INSERT(&dbjob, tbl_follow)
+		ADD_REDIS(&dbjob, redis, []interface{}{"ZADD", "follow:1", now, "2"}, []interface{}{"ZREM", "follow:1", "2"})
+		dbjob.Run(db)
+*/
+type DB_RedisExecutor interface {
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+type dbJobRedis struct {
+	redis      DB_RedisExecutor
+	command    []interface{}
+	compensate []interface{}
+}
+
+func ADD_REDIS(dbjob *DBJob, redis DB_RedisExecutor, command []interface{}, compensate []interface{}) error {
+	var err error = nil
+
+	if redis == nil || 1 > len(command) {
+		logger.Error("[ DBJob Error ] AddJob - ADD_REDIS without redis or command")
+		err = errors.New("[ DBJob Error ] AddJob - ADD_REDIS without redis or command")
+	} else if err = redis_CheckArgs(command, compensate); err != nil {
+		logger.Error("[ DBJob Error ] AddJob - ", err)
+	} else {
+		job := &dbJobRedis{
+			redis:      redis,
+			command:    append([]interface{}(nil), command...),
+			compensate: append([]interface{}(nil), compensate...),
+		}
+		dbjob.queryList = append(dbjob.queryList, dbJobQuery{
+			kind:  "REDIS",
+			redis: job,
+			build: func(d DB_Dialect) (string, []interface{}, error) {
+				return strings.TrimSuffix(fmt.Sprintln(job.command...), "\n"), nil, nil
+			},
+		})
+	}
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+/*
+	A dry run records the Redis commands and compensations instead of sending them.
+	dry run 은 Redis 명령과 보상 명령을 보내지 않고 기록함.
+*/
+func (dbjob *DBJob) redisOf(job *dbJobRedis) DB_RedisExecutor {
+	if dbjob.dry != nil {
+		return dbjob.dry
+	}
+	return job.redis
+}
+
+/*
+	Runs the Redis jobs after commit. On a failure, runs the compensations of the done ones in reverse order.
+	commit 후 Redis job 들을 실행. 실패하면 실행된 job 들의 보상 명령을 역순으로 실행.
+*/
+func (dbjob *DBJob) runRedis(ctx context.Context, results []DB_JobResult) error {
+	var done []int
+	for i, job := range dbjob.queryList {
+		if job.redis == nil || true == results[i].dropped {
+			continue
+		}
+
+		start := time.Now()
+		reply, err := dbjob.redisOf(job.redis).Do(ctx, job.redis.command...)
+		results[i].Elapsed = time.Since(start)
+		if err == nil {
+			results[i].Reply, results[i].Skipped = reply, false
+			done = append(done, i)
+			continue
+		}
+
+		logger.Errorf("[ DBJob ERROR ] Job index : %v - Redis job failed - %v", i, err)
+		err = errors.New(fmt.Sprint("[ DBJob Error ] Job index : ", i, " - Redis job failed - ", err))
+
+		for k := len(done) - 1; 0 <= k; k-- {
+			compensate := dbjob.queryList[done[k]].redis
+			if 1 > len(compensate.compensate) {
+				continue
+			}
+			if _, comp_err := dbjob.redisOf(compensate).Do(context.WithoutCancel(ctx), compensate.compensate...); comp_err != nil {
+				logger.Errorf("[ DBJob ERROR ] Job index : %v - Redis compensation failed - %v", done[k], comp_err)
+				err = errors.Join(err, errors.New(fmt.Sprint("[ DBJob Error ] Job index : ", done[k], " - Redis compensation failed - ", comp_err)))
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+/*
+	< Savepoint >
+	ADD_SAVEPOINT ~ ADD_RELEASE_SAVEPOINT make a named job group inside the transaction.
+	ADD_ROLLBACK_TO_SAVEPOINT undoes the jobs of the group done so far, the transaction goes on.
+	A group opened with ADD_TRY_SAVEPOINT is optional: when one of its jobs fails, the transaction rolls back to the savepoint,
+	skips the rest of the group and goes on after ADD_RELEASE_SAVEPOINT. A failure outside of an optional group rolls back everything.
+	ADD_SAVEPOINT ~ ADD_RELEASE_SAVEPOINT 는 트랜잭션 안에 이름 있는 job 그룹을 만듦.
+	ADD_ROLLBACK_TO_SAVEPOINT 는 그룹에서 지금까지 실행한 job 을 되돌리며, 트랜잭션은 계속 진행.
+	ADD_TRY_SAVEPOINT 로 연 그룹은 선택적: 그룹의 job 이 실패하면 savepoint 까지 롤백하고,
+	그룹의 나머지를 건너뛰어 ADD_RELEASE_SAVEPOINT 다음부터 진행. 선택적 그룹 밖의 실패는 전체를 롤백.
+
+	ex)
+		ADD_DECR(&dbjob, tbl_gold, tbl_where, 100)		<- buy item
+		ADD_INSERT(&dbjob, tbl_item)
+		ADD_TRY_SAVEPOINT(&dbjob, "bonus")
+		ADD_INSERT(&dbjob, tbl_bonus_item)				<- if this fails, only the bonus is undone
+		ADD_RELEASE_SAVEPOINT(&dbjob, "bonus")
+		dbjob.Run(db)
+*/
+func ADD_SAVEPOINT(dbjob *DBJob, name string) error {
+	return dbjob.addSavepointJob("SAVEPOINT", name, false)
+}
+
+func ADD_TRY_SAVEPOINT(dbjob *DBJob, name string) error {
+	return dbjob.addSavepointJob("SAVEPOINT", name, true)
+}
+
+func ADD_RELEASE_SAVEPOINT(dbjob *DBJob, name string) error {
+	return dbjob.addSavepointJob("RELEASE SAVEPOINT", name, false)
+}
+
+func ADD_ROLLBACK_TO_SAVEPOINT(dbjob *DBJob, name string) error {
+	return dbjob.addSavepointJob("ROLLBACK TO SAVEPOINT", name, false)
+}
+
+func (dbjob *DBJob) addSavepointJob(kind string, name string, try bool) error {
+	var err error = nil
+
+	if false == db_IsIdentifier(name) {
+		logger.Error("[ DBJob Error ] AddJob - Invalid savepoint name - ", name)
+		err = errors.New(fmt.Sprint("[ DBJob Error ] AddJob - Invalid savepoint name - ", name))
+	} else {
+		dbjob.queryList = append(dbjob.queryList, dbJobQuery{
+			kind:      kind,
+			savepoint: name,
+			try:       try,
+			build: func(d DB_Dialect) (string, []interface{}, error) {
+				return kind + " " + d.Quote(name) + ";", nil, nil
+			},
+		})
+	}
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+/*
+	< Defer >
+	A job made at run time inside the transaction, from the results of the jobs before it.
+	make_jobs adds the jobs with ADD_* on deferred, they run right there, and their results are in DB_JobResult.Deferred.
+	tx is the transaction of Run, so a value can also be read with DB_SELECT_Context(ctx, tx, ...) before adding the jobs.
+	make_jobs is called again when Run retries, so it must not keep state between calls.
+	트랜잭션 안에서, 앞선 job 들의 결과로 실행 시점에 만드는 job.
+	make_jobs 는 deferred 에 ADD_* 로 job 을 추가하며, 그 자리에서 실행되고 결과는 DB_JobResult.Deferred 에 담김.
+	tx 는 Run 의 트랜잭션이므로, job 을 추가하기 전에 DB_SELECT_Context(ctx, tx, ...) 로 값을 읽을 수도 있음.
+	Run 이 재시도하면 make_jobs 는 다시 호출되므로, 호출 사이에 상태를 유지하면 안 됨.
+
+	ex)
+		ADD_INSERT(&dbjob, tbl_mail)
+		ADD_DEFER(&dbjob, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error {
+			for i := range attachments {
+				attachments[i].MailID = results[0].LastInsertId
+			}
+			return ADD_INSERT(deferred, attachments...)
+		})
+		dbjob.Run(db)
+*/
+func ADD_DEFER(dbjob *DBJob, make_jobs func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error) error {
+	var err error = nil
+
+	if make_jobs == nil {
+		logger.Error("[ DBJob Error ] AddJob - ADD_DEFER without function")
+		err = errors.New("[ DBJob Error ] AddJob - ADD_DEFER without function")
+	} else {
+		dbjob.queryList = append(dbjob.queryList, dbJobQuery{
+			kind:     "DEFER",
+			deferred: make_jobs,
+			build: func(d DB_Dialect) (string, []interface{}, error) {
+				return "", nil, nil
+			},
+		})
+	}
+
+	dbjob.readyNextProcess(err)
+	return err
+}
+
+func db_IsIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (0 < i && '0' <= c && c <= '9') {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
+
+/*
+	Savepoint structure of the jobs. For each job, the optional group (ADD_TRY_SAVEPOINT job index) it is in, or -1,
+	and for each savepoint job, the index of its ADD_RELEASE_SAVEPOINT job.
+	job 들의 savepoint 구조. 각 job 이 속한 선택적 그룹 (ADD_TRY_SAVEPOINT job 인덱스) 또는 -1,
+	그리고 각 savepoint job 의 ADD_RELEASE_SAVEPOINT job 인덱스.
+*/
+func (dbjob *DBJob) savepointGroups() ([]int, map[int]int, error) {
+	try_group := make([]int, len(dbjob.queryList))
+	release := make(map[int]int)
+	var open []int
+
+	for i, job := range dbjob.queryList {
+		try_group[i] = -1
+		for k := len(open) - 1; 0 <= k; k-- {
+			if true == dbjob.queryList[open[k]].try {
+				try_group[i] = open[k]
+				break
+			}
+		}
+
+		switch job.kind {
+		case "SAVEPOINT":
+			open = append(open, i)
+
+		case "RELEASE SAVEPOINT", "ROLLBACK TO SAVEPOINT":
+			k := len(open) - 1
+			for ; 0 <= k; k-- {
+				if dbjob.queryList[open[k]].savepoint == job.savepoint {
+					break
+				}
+			}
+			if 0 > k {
+				return nil, nil, errors.New(fmt.Sprint("[ DBJob Error ] Job No.", i+1, " - ", job.kind, " without SAVEPOINT - ", job.savepoint))
+			}
+
+			/*
+				RELEASE also releases the savepoints made after it.
+				RELEASE 는 그 이후에 만든 savepoint 도 함께 해제.
+			*/
+			if job.kind == "RELEASE SAVEPOINT" {
+				for _, sp := range open[k:] {
+					release[sp] = i
+				}
+				open = open[:k]
+			}
+		}
+	}
+
+	for _, sp := range open {
+		if true == dbjob.queryList[sp].try {
+			return nil, nil, errors.New(fmt.Sprint("[ DBJob Error ] ADD_TRY_SAVEPOINT without ADD_RELEASE_SAVEPOINT - ", dbjob.queryList[sp].savepoint))
+		}
+	}
+
+	return try_group, release, nil
+}
+
+/*
+	Result of each job after Run. Jobs skipped or rolled back to a savepoint by an optional group have Skipped == true.
+	LastInsertId is the first generated id of INSERT (UPSERT on MySQL / SQLite), read with RETURNING on PostgreSQL.
+	ADD_DEFER has the sum of Affected and the last LastInsertId of its jobs.
+	Run 이후 각 job 의 결과. 선택적 그룹에서 건너뛰거나 savepoint 로 롤백된 job 은 Skipped == true.
+	LastInsertId 는 INSERT (MySQL / SQLite 는 UPSERT 도) 로 생성된 첫 id 이며, PostgreSQL 은 RETURNING 으로 읽음.
+	ADD_DEFER 는 그 job 들의 Affected 합과 마지막 LastInsertId 를 가짐.
+*/
+type DB_JobResult struct {
+	Index        int // job index, from 0. job 인덱스, 0 부터.
+	Kind         string
+	Table        string
+	Affected     int64
+	Rows         int64 // rows read by ADD_SELECT. ADD_SELECT 로 읽은 row 수.
+	LastInsertId int64
+	Elapsed      time.Duration
+	SQL          string
+	Skipped      bool
+	Deferred     []DB_JobResult // jobs added by ADD_DEFER. ADD_DEFER 로 추가된 job 들.
+	Reply        interface{}    // reply of ADD_REDIS. ADD_REDIS 의 응답.
+	dropped      bool
+}
+
+func (dbjob *DBJob) Run(db DB_Executor) (int64, error) {
+	affCount, _, err := dbjob.RunResultsContext(context.Background(), db)
+	return affCount, err
+}
+
+func (dbjob *DBJob) RunContext(ctx context.Context, db DB_Executor) (int64, error) {
+	affCount, _, err := dbjob.RunResultsContext(ctx, db)
+	return affCount, err
+}
+
+/*
+	Run, with the result of each job. The results are nil when Run fails.
+	Run 과 같으며, 각 job 의 결과도 반환. Run 이 실패하면 결과는 nil.
+
+	ex)
+		ADD_INSERT(&dbjob, tbl_mail)
+		affected, results, err := dbjob.RunResults(db)
+		mail_id := results[0].LastInsertId
+*/
+func (dbjob *DBJob) RunResults(db DB_Executor) (int64, []DB_JobResult, error) {
+	return dbjob.RunResultsContext(context.Background(), db)
+}
+
+type db_TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+/*
+	Every job runs in one transaction, which is committed only when all jobs succeed.
+	db is a *sql.DB or *sql.Conn, Run opens the transaction with the isolation level / read only mode set on the DBJob.
+	db can also be a *sql.Tx the caller opened, then the jobs run in it and the caller commits or rolls back.
+	ctx is checked before each job, a canceled ctx stops the remaining jobs and returns ctx.Err().
+	모든 job 은 하나의 트랜잭션에서 실행되며, 모든 job 이 성공해야 commit 됨.
+	db 가 *sql.DB 나 *sql.Conn 이면, DBJob 에 지정한 격리 수준 / 읽기 전용 모드로 트랜잭션을 엶.
+	db 가 호출자가 연 *sql.Tx 이면, job 은 그 안에서 실행되며 commit / rollback 은 호출자가 함.
+	각 job 전에 ctx 를 확인하며, 취소된 ctx 는 남은 job 을 중단하고 ctx.Err() 를 반환.
+*/
+func (dbjob *DBJob) RunResultsContext(ctx context.Context, db DB_Executor) (int64, []DB_JobResult, error) {
+	var err error = nil
+	if 0 != len(dbjob.errorMap) {
+		for k, v := range dbjob.errorMap {
+			logger.Errorf("[ DBJob Error ] Run Failed - AddJob was failed. ::: No.%v - %v", k, v)
+		}
+		return 0, nil, errors.New("[ DBJob Error ] Run Failed.")
 	}
 
-	return affect, err
-}
+	if 1 > len(dbjob.queryList) {
+		logger.Error("[ DBJob Error ] Run Failed. No Jobs")
+		return 0, nil, errors.New("[ DBJob Error ] Run Failed. No Jobs")
+	}
 
-func DB_INCR[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, size int64, raw_condition ...string) (int64, error) {
+	var exec DB_Executor = db
+	if with, ok := db.(db_DialectExecutor); ok {
+		exec = with.DB_Executor
+	}
 
 	/*
-		Check that each table type is the same.
-		각 테이블 타입이 동일한지 체크.
+		A dry run goes on a copy marked with the recorder, so the jobs of the caller are not changed.
+		dry run 은 recorder 를 표시한 복사본으로 진행하므로, 호출자의 job 은 바뀌지 않음.
 	*/
-	{
-		tb_col_t := reflect.TypeOf(&tbl_target)
-		tb_where_t := reflect.TypeOf(&tbl_where)
+	if dry, ok := exec.(*DB_DryRun); ok && dbjob.dry == nil {
+		preview := *dbjob
+		preview.dry = dry
+		return preview.RunResultsContext(ctx, db)
+	}
 
-		if tb_col_t.Name() != tb_where_t.Name() {
-			logger.Errorf("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name())
-			return 0, errors.New(fmt.Sprint("[ SQL ERROR ] SQL Table Not Same -", tb_col_t.Elem().Name(), ":", tb_where_t.Elem().Name()))
+	/*
+		Build every job's SQL with the dialect of db before touching the database.
+		DB 에 요청하기 전에 db 의 방언으로 모든 job 의 SQL 을 생성.
+	*/
+	d := db_DialectOf(db)
+	var queries []string
+	var queryArgs [][]interface{}
+	for i, job := range dbjob.queryList {
+		query, args, err := job.build(d)
+		if err != nil {
+			logger.Errorf("[ DBJob Error ] Run Failed - Job No.%v build failed - %v", i+1, err)
+			return 0, nil, err
 		}
+		queries = append(queries, query)
+		queryArgs = append(queryArgs, args)
 	}
 
-	queryStr, err := db_Make_INCR_Query(tbl_target, tbl_where, size, raw_condition...)
+	try_group, release, err := dbjob.savepointGroups()
 	if err != nil {
-		return 0, err
+		logger.Error(err)
+		return 0, nil, err
 	}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
-		return 0, err
+	_, own_tx := exec.(db_TxBeginner)
+	if false == own_tx && dbjob.txOptions != (sql.TxOptions{}) {
+		logger.Error("[ DBJob Error ] Isolation level / read only can not be set on the transaction of the caller")
+		return 0, nil, errors.New("[ DBJob Error ] Isolation level / read only can not be set on the transaction of the caller")
 	}
 
-	affect, err := res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
+	/*
+		Redis jobs run after commit, which Run does not do on the transaction of the caller.
+		Redis job 은 commit 후에 실행되는데, 호출자의 트랜잭션에서는 Run 이 commit 하지 않음.
+	*/
+	if false == own_tx {
+		for _, job := range dbjob.queryList {
+			if job.redis != nil {
+				logger.Error("[ DBJob Error ] Redis job can not run on the transaction of the caller")
+				return 0, nil, errors.New("[ DBJob Error ] Redis job can not run on the transaction of the caller")
+			}
+		}
 	}
 
-	return affect, err
-}
-
-func DB_DECR[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, size int64, raw_condition ...string) (int64, error) {
-	return DB_INCR(db, tbl_target, tbl_where, size*-1, raw_condition...)
-}
-
-func DB_INSERT_SELECT[DB_Table interface{}](db *sql.DB, tbl_insert DB_Table, tbl_select DB_Table, raw_condition ...string) ([]DB_Table, error) {
-
 	/*
-		In the case of an auto-increment column, since it may be an empty column, we do not check that all columns have values.
-		자동 증가 컬럼의 경우, 비어있는 컬럼인 경우가 있으므로, 여기선 모든 컬럼에 값이 있는지 체크하지 않는다.
+		A deadlock / lock wait timeout re-runs the whole job list in a fresh transaction, as many as the retry policy allows.
+		The transaction of the caller can not be re-run, so it is never retried.
+		deadlock / lock wait timeout 이 나면, 재시도 정책이 허용하는 만큼 새 트랜잭션에서 job 목록 전체를 다시 실행.
+		호출자의 트랜잭션은 다시 실행할 수 없으므로 재시도하지 않음.
 	*/
+	for attempt := 1; ; attempt++ {
+		results, err := dbjob.runOnce(ctx, exec, d, queries, queryArgs, try_group, release)
+		if err == nil {
+			if err = dbjob.runRedis(ctx, results); err != nil {
+				return 0, nil, err
+			}
 
-	var retValues []DB_Table
-	queryStr, err := db_Make_INSERT_Query(tbl_insert)
-	if err != nil {
-		return retValues, err
-	}
+			var affCount int64 = 0
+			for _, result := range results {
+				affCount += result.Affected
+			}
+			return affCount, results, nil
+		}
 
-	res, err := db.Exec(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Exec error - %v", err)
-		return retValues, err
-	}
+		if false == own_tx || attempt > dbjob.retry.MaxRetry || false == db_IsRetryable(d, err) {
+			return 0, nil, err
+		}
 
-	_, err = res.RowsAffected()
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] Rows Affected error - %v", err)
-		return retValues, err
+		delay := dbjob.retry.delay(attempt)
+		logger.Errorf("[ DBJob ERROR ] Retry %v/%v after %v - %v", attempt, dbjob.retry.MaxRetry, delay, err)
+		if dbjob.retry.OnRetry != nil {
+			dbjob.retry.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
+}
 
-	queryStr, err = db_Make_SELECT_Query(tbl_select, tbl_insert, raw_condition...)
-	if err != nil {
-		return retValues, err
+/*
+	Runs the jobs once, in a new transaction when exec can begin one, or else in the transaction exec is.
+	job 들을 한 번 실행. exec 가 트랜잭션을 열 수 있으면 새 트랜잭션에서, 아니면 exec 자체인 트랜잭션에서 실행.
+*/
+func (dbjob *DBJob) runOnce(ctx context.Context, exec DB_Executor, d DB_Dialect, queries []string, queryArgs [][]interface{}, try_group []int, release map[int]int) ([]DB_JobResult, error) {
+	var err error = nil
+	var tx *sql.Tx = nil
+	if beginner, ok := exec.(db_TxBeginner); ok {
+		tx, err = beginner.BeginTx(ctx, &dbjob.txOptions)
+		if err != nil {
+			logger.Errorf("[ DBJob ERROR ] Begin error - %v", err)
+			return nil, db_ContextError(ctx, err)
+		}
+		exec = tx
 	}
 
-	/*
-		Save the source member variable index value to receive the value.
-		값을 받을 원본 멤버 변수 인덱스 값을 저장.
-	*/
-	var target_index []int
-	tbl_val := reflect.ValueOf(&tbl_select).Elem()
-	for i := 0; i < tbl_val.NumField(); i++ {
-		if true == db_IsUse(tbl_val.Field(i)) {
-			target_index = append(target_index, i)
+	rollback := func(cause error) error {
+		if tx == nil {
+			return cause
+		}
+		if rb_err := tx.Rollback(); rb_err != nil && false == errors.Is(rb_err, sql.ErrTxDone) {
+			logger.Errorf("[ DBJob ERROR ] Rollback error - %v", rb_err)
+			return errors.Join(cause, rb_err)
 		}
+		return cause
 	}
 
-	rows, err := db.Query(queryStr)
-	if err != nil {
-		logger.Errorf("[ SQL ERROR ] DB Query error - %v", err)
-		return retValues, err
+	results := make([]DB_JobResult, len(queries))
+	for i, job := range dbjob.queryList {
+		results[i] = DB_JobResult{Index: i, Kind: job.kind, Table: job.table, SQL: queries[i], Skipped: true}
 	}
 
-	for rows.Next() {
+	for i := 0; i < len(queries); i++ {
+		if err = ctx.Err(); err != nil {
+			logger.Errorf("[ DBJob ERROR ] Job index : %v - %v", i, err)
+			return nil, rollback(err)
+		}
 
-		/*
-			Set table member var address parameters to receive results.
-			결과 받을 테이블 주소 파라미터 셋팅.
-		*/
-		var obj DB_Table
-		DB_InitTable(&obj)
-		retT_val := reflect.ValueOf(&obj).Elem()
-		var target_ptr_list []interface{}
-		for _, d := range target_index {
-			target_ptr_list = append(target_ptr_list, retT_val.Field(d).Addr().Interface())
+		if dbjob.queryList[i].redis != nil {
+			continue
 		}
 
-		/*
-			Create result values sequentially on table objects.
-			테이블 객체에 순차적으로 결과 값 작성.
-		*/
-		err = rows.Scan(target_ptr_list...)
-		if err != nil {
-			return retValues, err
+		if dbjob.coalesce != nil && dbjob.queryList[i].insert != nil {
+			var end int
+			end, err = dbjob.execCoalesced(ctx, exec, d, i, results)
+			i = end - 1
+		} else {
+			err = dbjob.execJob(ctx, exec, d, i, queries[i], queryArgs[i], results)
 		}
+		if err != nil {
+			logger.Errorf("[ DBJob ERROR ] Job index : %v - %v", i, err)
 
-		retValues = append(retValues, obj)
-	}
+			var expect_err *DB_ExpectError
+			if true == errors.As(err, &expect_err) {
+				return nil, rollback(err)
+			}
 
-	return retValues, nil
-}
+			/*
+				A failure in an optional group rolls back to its savepoint, and goes on from its release.
+				A deadlock aborts the whole transaction, so it can not roll back to a savepoint.
+				선택적 그룹 안의 실패는 그 savepoint 까지 롤백하고, release 부터 계속 진행.
+				deadlock 은 트랜잭션 전체를 중단시키므로, savepoint 로 롤백할 수 없음.
+			*/
+			sp := try_group[i]
+			if sp < 0 || nil != ctx.Err() || true == db_IsRetryable(d, err) {
+				return nil, rollback(db_ContextError(ctx, err))
+			}
 
-func DB_UPDATE_SELECT[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, tbl_select DB_Table, raw_condition ...string) ([]DB_Table, error) {
+			if _, rb_err := exec.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+d.Quote(dbjob.queryList[sp].savepoint)+";"); rb_err != nil {
+				logger.Errorf("[ DBJob ERROR ] Rollback to savepoint error - %v", rb_err)
+				return nil, rollback(db_ContextError(ctx, errors.Join(err, rb_err)))
+			}
+			logger.Errorf("[ DBJob ERROR ] Rolled back to savepoint %v, skip to job index : %v", dbjob.queryList[sp].savepoint, release[sp])
 
-	var retValues []DB_Table
-	_, err := DB_UPDATE(db, tbl_target, tbl_where)
-	if err != nil {
-		return retValues, err
+			for k := sp + 1; k < release[sp]; k++ {
+				results[k].dropped = true
+			}
+			for k := sp + 1; k <= i; k++ {
+				results[k].Affected = 0
+				results[k].Rows = 0
+				results[k].LastInsertId = 0
+				results[k].Deferred = nil
+				results[k].Skipped = true
+			}
+			i = release[sp] - 1
+			continue
+		}
 	}
 
-	return DB_SELECT(db, tbl_select, tbl_where, raw_condition...)
-}
-
-func DB_UPSERT_SELECT[DB_Table interface{}](db *sql.DB, tbl_upsert DB_Table, tbl_select DB_Table, raw_condition ...string) ([]DB_Table, error) {
-
-	var retValues []DB_Table
-	_, err := DB_UPSERT(db, tbl_upsert)
-	if err != nil {
-		return retValues, err
+	if tx != nil {
+		if err = tx.Commit(); err != nil {
+			logger.Errorf("[ DBJob ERROR ] Commit error - %v", err)
+			return nil, db_ContextError(ctx, err)
+		}
 	}
 
-	return DB_SELECT(db, tbl_select, tbl_upsert, raw_condition...)
+	return results, nil
 }
 
-func DB_INCR_SELECT[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, size int64, raw_condition ...string) ([]DB_Table, error) {
-
-	var retValues []DB_Table
-	_, err := DB_INCR(db, tbl_target, tbl_where, size)
-	if err != nil {
-		return retValues, err
+/*
+	Runs one job and fills results[i]. An unmet DB_Expect is returned as DB_ExpectError.
+	job 하나를 실행하고 results[i] 를 채움. 충족하지 못한 DB_Expect 는 DB_ExpectError 로 반환.
+*/
+func (dbjob *DBJob) execJob(ctx context.Context, exec DB_Executor, d DB_Dialect, i int, query string, args []interface{}, results []DB_JobResult) error {
+	job := dbjob.queryList[i]
+	result := &results[i]
+	start := time.Now()
+	defer func() { result.Elapsed = time.Since(start) }()
+
+	if job.kind == "DEFER" {
+		return dbjob.execDeferred(ctx, exec, d, i, results)
 	}
 
-	return DB_SELECT(db, tbl_target, tbl_where, raw_condition...)
-}
-
-func DB_DECR_SELECT[DB_Table interface{}](db *sql.DB, tbl_target DB_Table, tbl_where DB_Table, size int64, raw_condition ...string) ([]DB_Table, error) {
+	if err := dbjob.execQuery(ctx, exec, d, i, query, args, result); err != nil {
+		return err
+	}
 
-	var retValues []DB_Table
-	_, err := DB_DECR(db, tbl_target, tbl_where, size)
-	if err != nil {
-		return retValues, err
+	count := result.Affected
+	if job.read != nil {
+		count = result.Rows
 	}
 
-	return DB_SELECT(db, tbl_target, tbl_where, raw_condition...)
-}
+	if job.expect != nil && dbjob.dry == nil && false == job.expect.check(count) {
+		expect_err := &DB_ExpectError{Index: i, Kind: job.kind, Expect: *job.expect, Affected: count}
+		logger.Error(expect_err)
+		return expect_err
+	}
 
-type DBJob struct {
-	queryList  []string
-	jobCounter int
-	errorMap   map[int]error
+	return nil
 }
 
-func (dbjob *DBJob) readyNextProcess(err error) {
-	dbjob.jobCounter += 1
-	if err != nil {
-		dbjob.errorMap[dbjob.jobCounter] = err
+func (dbjob *DBJob) execDeferred(ctx context.Context, exec DB_Executor, d DB_Dialect, i int, results []DB_JobResult) error {
+	deferred := DBJob{dry: dbjob.dry, dialect: d}
+	if err := dbjob.queryList[i].deferred(ctx, DB_WithDialect(exec, d), results[:i], &deferred); err != nil {
+		return err
 	}
-}
 
-func ADD_INSERT[DB_TABLE interface{}](dbjob *DBJob, tbl_insert ...DB_TABLE) error {
-	var err error = nil
+	if 0 != len(deferred.errorMap) {
+		for k, v := range deferred.errorMap {
+			logger.Errorf("[ DBJob Error ] Job index : %v - deferred AddJob was failed. ::: No.%v - %v", i, k, v)
+		}
+		return errors.New(fmt.Sprint("[ DBJob Error ] Job index : ", i, " - deferred AddJob was failed"))
+	}
 
-	for {
-		if 1 > len(tbl_insert) {
-			logger.Error("[ DBJob Error ] AddJob - no Job added")
-			err = errors.New("[ DBJob Error ] AddJob - no Job added")
-			break
+	result := &results[i]
+	result.Skipped = false
+	result.Deferred = make([]DB_JobResult, len(deferred.queryList))
+	for k, job := range deferred.queryList {
+		if "" != job.savepoint || job.redis != nil {
+			return errors.New(fmt.Sprint("[ DBJob Error ] Job index : ", i, " - savepoint / Redis job can not be added in ADD_DEFER"))
 		}
 
-		tbl_name := reflect.TypeOf(tbl_insert[0]).Name()
+		query, args, err := job.build(d)
+		if err != nil {
+			return err
+		}
 
-		for _, tbl_in := range tbl_insert {
-			elemTbl_name := reflect.TypeOf(tbl_in).Name()
-			if tbl_name != elemTbl_name {
-				logger.Error("[ DBJob Error ] AddJob - Insert Job's element table name is not same")
-				err = errors.New("[ DBJob Error ] AddJob - Insert Job's element table name is not same")
-				break
+		result.Deferred[k] = DB_JobResult{Index: k, Kind: job.kind, Table: job.table, SQL: query, Skipped: true}
+		if err = deferred.execJob(ctx, exec, d, k, query, args, result.Deferred); err != nil {
+			var expect_err *DB_ExpectError
+			if true == errors.As(err, &expect_err) {
+				expect_err.Index = i
 			}
+			return err
 		}
 
-		str, err := db_Make_INSERT_Query(tbl_insert...)
-		if err != nil {
-			break
+		result.Affected += result.Deferred[k].Affected
+		result.Rows += result.Deferred[k].Rows
+		if 0 != result.Deferred[k].LastInsertId {
+			result.LastInsertId = result.Deferred[k].LastInsertId
 		}
-
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
 	}
 
-	dbjob.readyNextProcess(err)
-	return err
+	return nil
 }
 
-func ADD_UPDATE[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, raw_condition ...string) error {
-	var err error = nil
+func (dbjob *DBJob) execQuery(ctx context.Context, exec DB_Executor, d DB_Dialect, i int, query string, args []interface{}, result *DB_JobResult) error {
+	job := dbjob.queryList[i]
 
-	for {
-		str, err := db_Make_UPDATE_Query(tbl_target, tbl_where, raw_condition...)
+	if job.read != nil {
+		rows, err := job.read(ctx, DB_WithDialect(exec, d))
 		if err != nil {
-			break
+			return err
 		}
-
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
+		result.Rows, result.Skipped = rows, false
+		return nil
 	}
 
-	dbjob.readyNextProcess(err)
-	return err
-}
-
-func ADD_UPSERT[DB_TABLE interface{}](dbjob *DBJob, tbl_upsert DB_TABLE) error {
-	var err error = nil
-
-	for {
-		str, err := db_Make_UPSERT_Query(tbl_upsert)
+	if job.kind == "INSERT" && job.auto_column != "" && false == d.SupportsLastInsertId() {
+		id, affect, err := db_QueryReturning(ctx, exec, d, query, args, job.auto_column)
 		if err != nil {
-			break
+			return err
 		}
+		result.Affected, result.LastInsertId, result.Skipped = affect, id, false
+		return nil
+	}
 
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
+	logger.Info(query, args)
+	res, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
 	}
+	result.Skipped = false
 
-	dbjob.readyNextProcess(err)
-	return err
-}
+	if "" != job.savepoint {
+		return nil
+	}
 
-func ADD_DELETE[DB_TABLE interface{}](dbjob *DBJob, tbl_where DB_TABLE, raw_condition ...string) error {
-	var err error = nil
+	result.Affected, err = res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ DBJob ERROR ] Job index : %v - Rows Affected error - %v", i, err)
+		if job.expect != nil {
+			return err
+		}
+	}
 
-	for {
-		str, err := db_Make_DELETE_Query(tbl_where, raw_condition...)
+	if (job.kind == "INSERT" || job.kind == "UPSERT") && true == d.SupportsLastInsertId() {
+		result.LastInsertId, err = res.LastInsertId()
 		if err != nil {
-			break
+			logger.Errorf("[ DBJob ERROR ] Job index : %v - LastInsertId error - %v", i, err)
+		}
+		if job.kind == "INSERT" {
+			result.LastInsertId = db_FirstInsertId(d, result.LastInsertId, result.Affected)
 		}
-
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
 	}
 
-	dbjob.readyNextProcess(err)
-	return err
+	return nil
 }
 
-func ADD_INCR[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, size int64, raw_condition ...string) error {
-	var err error = nil
+/*
+	SQLite gives the id of the last row of a multi-row INSERT, MySQL gives the first.
+	SQLite 는 multi-row INSERT 의 마지막 row id 를, MySQL 은 첫 row id 를 줌.
+*/
+func db_FirstInsertId(d DB_Dialect, last_id int64, rows int64) int64 {
+	if d.Name() == "sqlite" && 0 != last_id && 1 < rows {
+		return last_id - rows + 1
+	}
+	return last_id
+}
 
-	for {
-		str, err := db_Make_INCR_Query(tbl_target, tbl_where, size, raw_condition...)
-		if err != nil {
+/*
+	Runs the ADD_INSERT job i with the following ADD_INSERT jobs of the same head, in chunks of DB_CoalescePolicy.
+	Returns the index after the last job of the run, also on error. (so an optional group skips all of them)
+	ADD_INSERT job i 를 같은 head 의 뒤따르는 ADD_INSERT job 들과 함께, DB_CoalescePolicy 단위로 나눠 실행.
+	오류가 나도 묶음의 마지막 job 다음 인덱스를 반환. (선택적 그룹이 그 job 들을 모두 건너뛰도록)
+*/
+func (dbjob *DBJob) execCoalesced(ctx context.Context, exec DB_Executor, d DB_Dialect, i int, results []DB_JobResult) (int, error) {
+	head, rows, err := dbjob.queryList[i].insert(d)
+	if err != nil {
+		return i + 1, err
+	}
+	auto_column := dbjob.queryList[i].auto_column
+
+	owners := make([]int, len(rows))
+	for r := range owners {
+		owners[r] = i
+	}
+
+	end := i + 1
+	for ; end < len(dbjob.queryList); end++ {
+		if dbjob.queryList[end].insert == nil {
 			break
 		}
-
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
+		next_head, next_rows, err := dbjob.queryList[end].insert(d)
+		if err != nil || next_head != head {
+			break
+		}
+		for range next_rows {
+			owners = append(owners, end)
+		}
+		rows = append(rows, next_rows...)
 	}
 
-	dbjob.readyNextProcess(err)
-	return err
-}
+	started := make(map[int]bool)
+	max_placeholders, max_bytes := dbjob.coalesce.limits(d)
+
+	for start := 0; start < len(rows); {
+		stop := start
+		placeholders := 0
+		size := len(head) + 1
+		for stop < len(rows) {
+			tuple, row_args := db_InsertTuple(rows[stop])
+			row_size := len(tuple) + 2
+			for _, arg := range row_args {
+				switch v := arg.(type) {
+				case string:
+					row_size += len(v)
+				case []byte:
+					row_size += len(v)
+				default:
+					row_size += 24
+				}
+			}
+			if stop > start && (placeholders+len(row_args) > max_placeholders || size+row_size > max_bytes) {
+				break
+			}
+			placeholders += len(row_args)
+			size += row_size
+			stop++
+		}
 
-func ADD_DECR[DB_TABLE interface{}](dbjob *DBJob, tbl_target DB_TABLE, tbl_where DB_TABLE, size int64, raw_condition ...string) error {
-	var err error = nil
+		tuples := make([]string, stop-start)
+		var args []interface{}
+		for r := start; r < stop; r++ {
+			tuple, row_args := db_InsertTuple(rows[r])
+			tuples[r-start] = tuple
+			args = append(args, row_args...)
+		}
+		query := d.Rebind(head + strings.Join(tuples, ", ") + ";")
 
-	for {
-		str, err := db_Make_INCR_Query(tbl_target, tbl_where, -1*size, raw_condition...)
+		chunk_start := time.Now()
+		ids, affected, err := db_ExecInsertIDs(ctx, exec, d, query, args, auto_column, int64(stop-start))
 		if err != nil {
-			break
+			return end, err
 		}
+		elapsed := time.Since(chunk_start)
 
-		dbjob.queryList = append(dbjob.queryList, str)
-		break
+		/*
+			Which rows of the statement were not inserted is unknown, so Run fails and rolls back.
+			구문의 어느 row 가 INSERT 되지 않았는지 알 수 없으므로, Run 은 실패하고 롤백.
+		*/
+		if affected != int64(stop-start) && dbjob.dry == nil {
+			logger.Errorf("[ DBJob ERROR ] Job index : %v - Coalesced INSERT affected %v rows, but has %v rows", owners[start], affected, stop-start)
+			return end, errors.New(fmt.Sprint("[ DBJob Error ] Job index : ", owners[start], " - Coalesced INSERT affected ", affected, " rows, but has ", stop-start, " rows"))
+		}
+
+		for r := start; r < stop; r++ {
+			result := &results[owners[r]]
+			if false == started[owners[r]] {
+				started[owners[r]] = true
+				result.SQL = query
+				result.Skipped = false
+				if r-start < len(ids) {
+					result.LastInsertId = ids[r-start]
+				}
+			}
+			if r == start || owners[r] != owners[r-1] {
+				result.Elapsed += elapsed
+			}
+			result.Affected++
+		}
+
+		start = stop
 	}
 
-	dbjob.readyNextProcess(err)
-	return err
+	return end, nil
 }
 
-func (dbjob *DBJob) Run(db *sql.DB) (int64, error) {
-	var err error = nil
-	if 0 != len(dbjob.errorMap) {
-		for k, v := range dbjob.errorMap {
-			logger.Errorf("[ DBJob Error ] Run Failed - AddJob was failed. ::: No.%v", k, " - %v", v)
-		}
-		return 0, errors.New("[ DBJob Error ] Run Failed.")
+/*
+	Runs a multi-row INSERT and returns the id of each row and the affected row count, ids are read with RETURNING when the dialect has no LastInsertId.
+	Without an auto increment column, or when the ids are not known, ids is empty. (ex. affected is not the row count)
+	multi-row INSERT 를 실행하고 각 row 의 id 와 affected row 수를 반환, LastInsertId 가 없는 방언은 RETURNING 으로 읽음.
+	자동 증가 컬럼이 없거나 id 를 알 수 없으면 ids 는 비어있음. (ex. affected 가 row 수와 다름)
+*/
+func db_ExecInsertIDs(ctx context.Context, exec DB_Executor, d DB_Dialect, query string, args []interface{}, auto_column string, rows int64) ([]int64, int64, error) {
+	if auto_column != "" && false == d.SupportsLastInsertId() {
+		ids, err := db_QueryReturningIDs(ctx, exec, d, query, args, auto_column)
+		return ids, int64(len(ids)), err
 	}
 
-	if 1 > len(dbjob.queryList) {
-		logger.Error("[ DBJob Error ] Run Failed. No Jobs")
-		return 0, errors.New("[ DBJob Error ] Run Failed. No Jobs")
+	logger.Info(query, args)
+	res, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	var tx *sql.Tx = nil
-	var res sql.Result
-	var affCount int64 = 0
-
-	if 1 < dbjob.jobCounter {
-		tx, err = db.Begin()
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.Errorf("[ DBJob ERROR ] Rows Affected error - %v", err)
+		affected = rows
 	}
 
-	for i, query := range dbjob.queryList {
-		res, err = db.Exec(query)
-		if err != nil {
-			logger.Errorf("[ DBJob ERROR ] Job index : %v", i, " - %v", err)
-			if tx != nil {
-				tx.Rollback()
-			}
-			return 0, err
-		}
-		affect, err := res.RowsAffected()
-		if err != nil {
-			logger.Errorf("[ DBJob ERROR ] Job index : %v ", i, " - Rows Affected error - %v ", err)
-		}
-		affCount += affect
+	if false == d.SupportsLastInsertId() || affected != rows {
+		return nil, affected, nil
+	}
+	last_id, err := res.LastInsertId()
+	if err != nil || 0 == last_id {
+		return nil, affected, nil
 	}
 
-	if tx != nil {
-		tx.Commit()
+	first_id := db_FirstInsertId(d, last_id, rows)
+	ids := make([]int64, rows)
+	for r := range ids {
+		ids[r] = first_id + int64(r)
 	}
 
-	return affCount, err
+	return ids, affected, nil
 }
 
 
@@ -1152,7 +4832,7 @@ func main() {
 	// // tbl_where.GameDBID = 1
 	// // tbl_where.UserUUID = 10
 
-	// tbl_arr, err := DB_SELECT(db, tbl_select, tbl_where, "ORDER BY UserUUID DESC limit 2")
+	// tbl_arr, err := DB_SELECT(db, tbl_select, tbl_where, DB_OrderBy("UserUUID", DB_DESC), DB_Limit(2))
 	// for _, d := range tbl_arr {
 	// 	fmt.Println(d)
 	// }
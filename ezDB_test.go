@@ -0,0 +1,1580 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+/*
+In memory SQLite database with the given tables, one connection so every query sees the same database.
+주어진 테이블을 가진 메모리 SQLite DB, 모든 쿼리가 같은 DB 를 보도록 연결은 하나.
+*/
+func test_OpenSQLite(t *testing.T, ddl ...string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	DB_SetDialect(db, DB_SQLite)
+	t.Cleanup(func() { db.Close() })
+
+	for _, query := range ddl {
+		if _, err = db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+/*
+Fake database which records each statement and answers with the hooks of the test.
+exec returns the affected row count of a statement, query returns the columns and rows of a query.
+테스트의 hook 으로 응답하며 각 구문을 기록하는 가짜 DB.
+exec 는 구문의 affected row 수를, query 는 쿼리의 컬럼과 row 들을 반환.
+*/
+type test_FakeDB struct {
+	mutex      sync.Mutex
+	statements []string
+	last_id    int64
+	exec       func(query string, args []driver.NamedValue) (int64, error)
+	query      func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error)
+}
+
+func test_OpenFake(t *testing.T, d DB_Dialect) (*sql.DB, *test_FakeDB) {
+	t.Helper()
+
+	fake := &test_FakeDB{}
+	db := sql.OpenDB(test_FakeConnector{fake: fake})
+	db.SetMaxOpenConns(1)
+	DB_SetDialect(db, d)
+	t.Cleanup(func() { db.Close() })
+
+	return db, fake
+}
+
+func (fake *test_FakeDB) record(query string) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.statements = append(fake.statements, query)
+}
+
+func (fake *test_FakeDB) Statements() []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return append([]string(nil), fake.statements...)
+}
+
+type test_FakeConnector struct{ fake *test_FakeDB }
+type test_FakeConn struct{ fake *test_FakeDB }
+type test_FakeTx struct{ fake *test_FakeDB }
+type test_FakeResult struct{ last_id, affected int64 }
+
+type test_FakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (c test_FakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return test_FakeConn{fake: c.fake}, nil
+}
+func (c test_FakeConnector) Driver() driver.Driver { return nil }
+
+func (c test_FakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+func (c test_FakeConn) Close() error { return nil }
+func (c test_FakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+func (c test_FakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c test_FakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.fake.record("BEGIN;")
+	return test_FakeTx{fake: c.fake}, nil
+}
+
+func (c test_FakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.fake.record(query)
+
+	var affected int64 = 1
+	if c.fake.exec != nil {
+		var err error
+		if affected, err = c.fake.exec(query, args); err != nil {
+			return nil, err
+		}
+	}
+
+	c.fake.mutex.Lock()
+	defer c.fake.mutex.Unlock()
+	c.fake.last_id++
+	return test_FakeResult{last_id: c.fake.last_id, affected: affected}, nil
+}
+
+func (c test_FakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.fake.record(query)
+
+	if c.fake.query == nil {
+		return &test_FakeRows{}, nil
+	}
+	columns, rows, err := c.fake.query(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &test_FakeRows{columns: columns, rows: rows}, nil
+}
+
+func (tx test_FakeTx) Commit() error   { tx.fake.record("COMMIT;"); return nil }
+func (tx test_FakeTx) Rollback() error { tx.fake.record("ROLLBACK;"); return nil }
+
+func (r test_FakeResult) LastInsertId() (int64, error) { return r.last_id, nil }
+func (r test_FakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+func (r *test_FakeRows) Columns() []string { return r.columns }
+func (r *test_FakeRows) Close() error      { return nil }
+
+func (r *test_FakeRows) Next(dest []driver.Value) error {
+	if 0 == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type test_Profile struct {
+	ProfileID int64 `PK:"true"`
+	Name      string
+	Level     int       `Null:"true"`
+	Memo      string    `Null:"true"`
+	LoginTime time.Time `Null:"true"`
+	Guild     string    `Null:"false"`
+}
+
+func (test_Profile) TableName() string { return "profile" }
+
+const test_ProfileDDL = `CREATE TABLE profile (
+	ProfileID INTEGER PRIMARY KEY AUTOINCREMENT,
+	Name      TEXT NOT NULL,
+	Level     INTEGER,
+	Memo      TEXT,
+	LoginTime DATETIME,
+	Guild     TEXT DEFAULT 'none'
+)`
+
+type test_Wallet struct {
+	WalletID DB_Field[int64] `PK:"true"`
+	Gold     DB_Field[int64]
+	Memo     DB_Field[string]
+}
+
+func (test_Wallet) TableName() string { return "wallet" }
+
+type test_Account struct {
+	UserUUID  int64  `db:"user_uuid" PK:"true"`
+	PlayerKey string `db:"player_key"`
+	Cache     string `db:"-"`
+}
+
+func (test_Account) TableName() string { return "accountdb.tblaccount" }
+
+func TestColumnTagsQuoted(t *testing.T) {
+	tests := []struct {
+		d          DB_Dialect
+		select_sql string
+		update_sql string
+	}{
+		{DB_MySQL, "SELECT `player_key` FROM `accountdb`.`tblaccount` WHERE `user_uuid` = ?;", "UPDATE `accountdb`.`tblaccount` SET `player_key`=? WHERE `user_uuid` = ?;"},
+		{DB_PostgreSQL, `SELECT "player_key" FROM "accountdb"."tblaccount" WHERE "user_uuid" = $1;`, `UPDATE "accountdb"."tblaccount" SET "player_key"=$1 WHERE "user_uuid" = $2;`},
+		{DB_SQLite, `SELECT "player_key" FROM "accountdb"."tblaccount" WHERE "user_uuid" = ?;`, `UPDATE "accountdb"."tblaccount" SET "player_key"=? WHERE "user_uuid" = ?;`},
+	}
+
+	for _, test := range tests {
+		db, fake := test_OpenFake(t, test.d)
+		fake.query = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return []string{"player_key"}, [][]driver.Value{{"guest_7"}}, nil
+		}
+
+		/*
+			db:"-" is not a column even when it has a value.
+		*/
+		var tbl_select, tbl_where test_Account
+		DB_InitTable(&tbl_select, &tbl_where)
+		tbl_select.PlayerKey = ""
+		tbl_select.Cache = "cache"
+		tbl_where.UserUUID = 7
+		rows, err := DB_SELECT(db, tbl_select, tbl_where)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 1 != len(rows) || "guest_7" != rows[0].PlayerKey {
+			t.Fatalf("%v got %+v", test.d, rows)
+		}
+
+		tbl_select.PlayerKey = "guest_8"
+		if _, err = DB_UPDATE(db, tbl_select, tbl_where); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{test.select_sql, test.update_sql}
+		if got := fake.Statements(); false == reflect.DeepEqual(want, got) {
+			t.Fatalf("%v statements\n got %q\nwant %q", test.d, got, want)
+		}
+	}
+}
+
+func TestFieldRoundTrip(t *testing.T) {
+	db := test_OpenSQLite(t, "CREATE TABLE wallet (WalletID INTEGER PRIMARY KEY, Gold INTEGER, Memo TEXT)")
+
+	/*
+		MaxInt64 and "\t\n0" are the "not set" values of plain fields, but real values in DB_Field.
+	*/
+	tbl_insert := test_Wallet{WalletID: DB_Set[int64](1), Gold: DB_Set[int64](math.MaxInt64), Memo: DB_Set(DB_UNUSE_STRING)}
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	var tbl_select, tbl_where test_Wallet
+	tbl_select.Gold.Set(0)
+	tbl_select.Memo.Set("")
+	tbl_where.WalletID.Set(1)
+	rows, err := DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || math.MaxInt64 != rows[0].Gold.Get() || DB_UNUSE_STRING != rows[0].Memo.Get() {
+		t.Fatalf("got %+v", rows)
+	}
+
+	/*
+		An unset field is left out, a NULL field writes NULL and reads back as NULL.
+	*/
+	var tbl_target test_Wallet
+	tbl_target.Memo.SetNull()
+	if _, err = DB_UPDATE(db, tbl_target, tbl_where); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || math.MaxInt64 != rows[0].Gold.Get() || false == rows[0].Memo.IsNull() {
+		t.Fatalf("got %+v", rows)
+	}
+
+	/*
+		NULL and NOT NULL become IS NULL and IS NOT NULL in WHERE.
+	*/
+	var null_where, not_null_where test_Wallet
+	null_where.Memo = DB_Null[string]()
+	not_null_where.Memo = DB_NotNull[string]()
+	if count, err := DB_COUNT(db, null_where); err != nil || 1 != count {
+		t.Fatalf("IS NULL count %v - %v", count, err)
+	}
+	if count, err := DB_COUNT(db, not_null_where); err != nil || 0 != count {
+		t.Fatalf("IS NOT NULL count %v - %v", count, err)
+	}
+
+	/*
+		NOT NULL can not be written.
+	*/
+	tbl_target.Memo.SetNotNull()
+	if _, err = DB_UPDATE(db, tbl_target, tbl_where); err == nil {
+		t.Fatal("UPDATE with NOT NULL did not fail")
+	}
+}
+
+func TestNullTaggedFieldRoundTrip(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL)
+
+	var tbl_insert test_Profile
+	DB_InitTable(&tbl_insert)
+	tbl_insert.ProfileID = 1
+	tbl_insert.Name = "alice"
+	tbl_insert.Guild = "red"
+	tbl_insert.Level = 3
+	if err := DB_SetNull(&tbl_insert.Memo); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB_SetNull(&tbl_insert.LoginTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	var memo, login sql.NullString
+	if err := db.QueryRow("SELECT Memo, LoginTime FROM profile").Scan(&memo, &login); err != nil {
+		t.Fatal(err)
+	}
+	if memo.Valid || login.Valid {
+		t.Fatalf("NULL was not written - Memo %v, LoginTime %v", memo, login)
+	}
+
+	/*
+		NULL read into a Null:"true" field is the NULL value, so writing it back writes NULL.
+	*/
+	var tbl_select, tbl_where test_Profile
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.ProfileID = 0
+	tbl_select.Level = 0
+	tbl_select.Memo = ""
+	tbl_where.Name = "alice"
+	rows, err := DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || 3 != rows[0].Level || DB_NULL_STRING != rows[0].Memo {
+		t.Fatalf("unexpected rows - %+v", rows)
+	}
+
+	var tbl_target test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Memo = rows[0].Memo
+	if err = DB_SetNull(&tbl_target.Level); err != nil {
+		t.Fatal(err)
+	}
+	tbl_where.ProfileID = rows[0].ProfileID
+	if _, err = DB_UPDATE(db, tbl_target, tbl_where); err != nil {
+		t.Fatal(err)
+	}
+
+	var level sql.NullInt64
+	if err = db.QueryRow("SELECT Level FROM profile").Scan(&level); err != nil {
+		t.Fatal(err)
+	}
+	if level.Valid {
+		t.Fatalf("Level is not NULL - %v", level)
+	}
+
+	/*
+		The NULL value in WHERE is IS NULL.
+	*/
+	DB_InitTable(&tbl_where)
+	DB_SetNull(&tbl_where.Level)
+	count, err := DB_COUNT(db, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != count {
+		t.Fatalf("IS NULL count - %v", count)
+	}
+}
+
+func TestNullTagFalse(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL)
+	if _, err := db.Exec("INSERT INTO profile (Name, Guild) VALUES ('bob', NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var tbl_select, tbl_where test_Profile
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.Guild = ""
+	tbl_where.Name = "bob"
+	if _, err := DB_SELECT(db, tbl_select, tbl_where); err == nil || false == strings.Contains(err.Error(), "without Null tag") {
+		t.Fatalf(`Null:"false" column read NULL without error - %v`, err)
+	}
+
+	/*
+		The NULL value of string is a plain value in a column without Null:"true".
+	*/
+	var tbl_insert test_Profile
+	DB_InitTable(&tbl_insert)
+	tbl_insert.ProfileID = 2
+	tbl_insert.Name = "carol"
+	DB_SetNull(&tbl_insert.Guild)
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	var guild sql.NullString
+	if err := db.QueryRow("SELECT Guild FROM profile WHERE Name = 'carol'").Scan(&guild); err != nil {
+		t.Fatal(err)
+	}
+	if false == guild.Valid || DB_NULL_STRING != guild.String {
+		t.Fatalf("Guild of carol - %v", guild)
+	}
+}
+
+type test_Counter struct {
+	CounterID int64 `PK:"true"`
+	Small     int8
+	Tiny      uint8
+}
+
+func (test_Counter) TableName() string { return "counter" }
+
+func TestNullValueUntagged(t *testing.T) {
+	db := test_OpenSQLite(t, "CREATE TABLE counter (CounterID INTEGER PRIMARY KEY, Small INTEGER, Tiny INTEGER)")
+	if _, err := db.Exec("INSERT INTO counter VALUES (1, 0, 254), (2, 0, 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	/*
+		-128 and 254 are the NULL values of int8 and uint8, but these columns have no Null:"true".
+	*/
+	var tbl_target, tbl_where test_Counter
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Small = -128
+	tbl_where.CounterID = 1
+	if _, err := DB_UPDATE(db, tbl_target, tbl_where); err != nil {
+		t.Fatal(err)
+	}
+
+	var small sql.NullInt64
+	if err := db.QueryRow("SELECT Small FROM counter WHERE CounterID = 1").Scan(&small); err != nil {
+		t.Fatal(err)
+	}
+	if false == small.Valid || -128 != small.Int64 {
+		t.Fatalf("Small - %v", small)
+	}
+
+	DB_InitTable(&tbl_where)
+	tbl_where.Tiny = 254
+	count, err := DB_COUNT(db, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != count {
+		t.Fatalf("COUNT of Tiny 254 - %v", count)
+	}
+}
+
+func TestMultiRowInsertDefault(t *testing.T) {
+	var first, second test_Profile
+	DB_InitTable(&first, &second)
+	first.Name = "alice"
+	first.Guild = "red"
+	second.Name = "bob"
+	second.Level = 2
+
+	/*
+		A column left unset by some rows is DEFAULT, not NULL.
+	*/
+	var dbjob DBJob
+	ADD_INSERT(&dbjob, first, second)
+	statements, err := dbjob.Preview(DB_MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "INSERT INTO `profile` (`Name`, `Level`, `Guild`) VALUES (?, DEFAULT, ?), (?, ?, DEFAULT);"
+	if 3 != len(statements) || want != statements[1].SQL || 4 != len(statements[1].Args) {
+		t.Fatalf("got %v", statements)
+	}
+
+	/*
+		SQLite has no DEFAULT in VALUES. Null:"true" columns get NULL, others are an error.
+	*/
+	db := test_OpenSQLite(t, test_ProfileDDL)
+	if _, err = dbjob.Run(db); err == nil {
+		t.Fatal("SQLite multi-row INSERT without Guild in every row did not fail")
+	}
+
+	second.Guild = "blue"
+	var retry DBJob
+	ADD_INSERT(&retry, first, second)
+	if _, err = retry.Run(db); err != nil {
+		t.Fatal(err)
+	}
+	var level sql.NullInt64
+	if err = db.QueryRow("SELECT Level FROM profile WHERE Name = 'alice'").Scan(&level); err != nil {
+		t.Fatal(err)
+	}
+	if level.Valid {
+		t.Fatalf("Level of alice - %v", level)
+	}
+}
+
+func TestAddJobCopiesAndChecks(t *testing.T) {
+	var dbjob DBJob
+
+	/*
+		An invalid job fails at ADD_*, not at Run.
+	*/
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_where.ProfileID = 1
+	if err := ADD_UPDATE(&dbjob, tbl_target, tbl_where, DB_Eq("NoSuchColumn", 1)); err == nil {
+		t.Fatal("ADD_UPDATE with an unknown column did not fail")
+	}
+	dbjob = DBJob{}
+
+	/*
+		The caller's slices can be changed after ADD_*.
+	*/
+	rows := make([]test_Profile, 1)
+	DB_InitTable(&rows[0])
+	rows[0].Name = "alice"
+	conditions := []interface{}{DB_Eq("Name", "alice")}
+	tbl_target.Level = 1
+	if err := ADD_INSERT(&dbjob, rows...); err != nil {
+		t.Fatal(err)
+	}
+	if err := ADD_UPDATE(&dbjob, tbl_target, tbl_where, conditions...); err != nil {
+		t.Fatal(err)
+	}
+	rows[0].Name = "bob"
+	conditions[0] = DB_Eq("Name", "bob")
+
+	statements, err := dbjob.Preview(DB_MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 4 != len(statements) || "alice" != statements[1].Args[0] || "alice" != statements[2].Args[2] {
+		t.Fatalf("got %v", statements)
+	}
+}
+
+func TestAddJobDialect(t *testing.T) {
+	var rows []test_Profile
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_where.ProfileID = 1
+
+	/*
+		SKIP LOCKED is fine on MySQL, the default, but fails at ADD_* on SQLite.
+	*/
+	var dbjob DBJob
+	if err := ADD_SELECT(&dbjob, &rows, tbl_target, tbl_where, DB_ForUpdate(), DB_SkipLocked()); err != nil {
+		t.Fatal(err)
+	}
+	dbjob = DBJob{}
+	dbjob.SetDialect(DB_SQLite)
+	if err := ADD_SELECT(&dbjob, &rows, tbl_target, tbl_where, DB_ForUpdate(), DB_SkipLocked()); err == nil {
+		t.Fatal("ADD_SELECT with SKIP LOCKED did not fail on SQLite")
+	}
+
+	/*
+		The SQL is logged when it runs, not when ADD_* checks it.
+	*/
+	var info strings.Builder
+	saved := logger
+	logger = &Logger{info: log.New(&info, "", 0), error: log.New(io.Discard, "", 0)}
+	t.Cleanup(func() { logger = saved })
+
+	db := test_OpenSQLite(t, test_ProfileDDL)
+	dbjob = DBJob{}
+	dbjob.SetDialect(DB_SQLite)
+	tbl_target.Level = 3
+	if err := ADD_UPDATE(&dbjob, tbl_target, tbl_where); err != nil {
+		t.Fatal(err)
+	}
+	if 0 != info.Len() {
+		t.Fatalf("logged at ADD_* - %q", info.String())
+	}
+	if _, err := dbjob.Run(db); err != nil {
+		t.Fatal(err)
+	}
+	if 1 != strings.Count(info.String(), "UPDATE") {
+		t.Fatalf("logged at Run - %q", info.String())
+	}
+}
+
+func TestWriteSelectConditions(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL)
+	if _, err := db.Exec("INSERT INTO profile (Name, Level, Guild) VALUES ('alice', 5, 'red'), ('bob', 1, 'red')"); err != nil {
+		t.Fatal(err)
+	}
+
+	/*
+		DB_Ge guards the DECR, DB_OrderBy orders the SELECT.
+	*/
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Level = 0
+	tbl_where.Guild = "red"
+	rows, err := DB_DECR_SELECT(db, tbl_target, tbl_where, 2, DB_Ge("Level", 2), DB_OrderBy("Name", DB_ASC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 2 != len(rows) || 3 != rows[0].Level || 1 != rows[1].Level {
+		t.Fatalf("got %+v", rows)
+	}
+}
+
+func TestSelectOptions(t *testing.T) {
+	tests := []struct {
+		d      DB_Dialect
+		offset string
+		page   string
+	}{
+		{DB_MySQL, "SELECT `Name` FROM `profile` WHERE `Guild` = ? ORDER BY `Level` DESC LIMIT 18446744073709551615 OFFSET 20;", "SELECT DISTINCT `Name` FROM `profile` WHERE `Guild` = ? ORDER BY `Level` DESC LIMIT 10 OFFSET 20;"},
+		{DB_PostgreSQL, `SELECT "Name" FROM "profile" WHERE "Guild" = $1 ORDER BY "Level" DESC OFFSET 20;`, `SELECT DISTINCT "Name" FROM "profile" WHERE "Guild" = $1 ORDER BY "Level" DESC LIMIT 10 OFFSET 20;`},
+		{DB_SQLite, `SELECT "Name" FROM "profile" WHERE "Guild" = ? ORDER BY "Level" DESC LIMIT -1 OFFSET 20;`, `SELECT DISTINCT "Name" FROM "profile" WHERE "Guild" = ? ORDER BY "Level" DESC LIMIT 10 OFFSET 20;`},
+	}
+
+	for _, test := range tests {
+		db, fake := test_OpenFake(t, test.d)
+
+		var tbl_select, tbl_where test_Profile
+		DB_InitTable(&tbl_select, &tbl_where)
+		tbl_select.Name = ""
+		tbl_where.Guild = "none"
+
+		/*
+			An unknown column fails before anything is sent.
+		*/
+		if _, err := DB_SELECT(db, tbl_select, tbl_where, DB_OrderBy("Levle", DB_ASC)); err == nil {
+			t.Fatalf("%v unknown ORDER BY column did not fail", test.d)
+		}
+		if _, err := DB_SELECT(db, tbl_select, tbl_where, DB_GroupBy("Gulid")); err == nil {
+			t.Fatalf("%v unknown GROUP BY column did not fail", test.d)
+		}
+		if 0 != len(fake.Statements()) {
+			t.Fatalf("%v sent %q", test.d, fake.Statements())
+		}
+
+		/*
+			OFFSET without LIMIT needs a LIMIT on MySQL and SQLite.
+		*/
+		if _, err := DB_SELECT(db, tbl_select, tbl_where, DB_OrderBy("Level", DB_DESC), DB_Offset(20)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DB_SELECT(db, tbl_select, tbl_where, DB_Distinct(), DB_OrderBy("Level", DB_DESC), DB_Limit(10), DB_Offset(20)); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{test.offset, test.page}
+		if got := fake.Statements(); false == reflect.DeepEqual(want, got) {
+			t.Fatalf("%v statements\n got %q\nwant %q", test.d, got, want)
+		}
+	}
+}
+
+type test_Score struct {
+	ScoreID int64 `PK:"true"`
+	Guild   string
+	Point   int64
+}
+
+func (test_Score) TableName() string { return "score" }
+
+func TestAggregates(t *testing.T) {
+	db := test_OpenSQLite(t, "CREATE TABLE score (ScoreID INTEGER PRIMARY KEY, Guild TEXT, Point INTEGER)")
+	for i, row := range []test_Score{{Guild: "red", Point: 10}, {Guild: "red", Point: 30}, {Guild: "blue", Point: 5}} {
+		row.ScoreID = int64(i + 1)
+		if _, err := DB_INSERT(db, row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tbl_init, tbl_target, tbl_group, tbl_where test_Score
+	DB_InitTable(&tbl_init, &tbl_target, &tbl_group, &tbl_where)
+
+	count, err := DB_COUNT(db, tbl_where)
+	if err != nil || 3 != count {
+		t.Fatalf("COUNT %v - %v", count, err)
+	}
+
+	tbl_target.Point = 0
+	tbl_where.Guild = "red"
+	aggregates := []struct {
+		fn   func(DB_Executor, test_Score, test_Score, ...interface{}) (test_Score, error)
+		want int64
+	}{{DB_SUM[test_Score], 40}, {DB_MAX[test_Score], 30}, {DB_MIN[test_Score], 10}}
+	for i, aggregate := range aggregates {
+		got, err := aggregate.fn(db, tbl_target, tbl_where)
+		if err != nil || aggregate.want != got.Point {
+			t.Fatalf("aggregate %v got %v - %v, want %v", i, got.Point, err, aggregate.want)
+		}
+	}
+
+	/*
+		SUM / MAX of no rows is NULL, the column is left as DB_InitTable made it.
+	*/
+	tbl_where.Guild = "green"
+	if got, err := DB_SUM(db, tbl_target, tbl_where); err != nil || tbl_init.Point != got.Point {
+		t.Fatalf("empty SUM got %v - %v", got.Point, err)
+	}
+	if got, err := DB_MAX(db, tbl_target, tbl_where); err != nil || tbl_init.Point != got.Point {
+		t.Fatalf("empty MAX got %v - %v", got.Point, err)
+	}
+
+	/*
+		GROUP BY with HAVING, the group column is filled in each row.
+	*/
+	DB_InitTable(&tbl_where)
+	tbl_group.Guild = ""
+	groups, err := DB_COUNT_GROUP(db, tbl_group, tbl_where, DB_Having(DB_Expr("COUNT(*) > ?", 1)))
+	if err != nil || 1 != len(groups) || "red" != groups[0].Group.Guild || 2 != groups[0].Count {
+		t.Fatalf("COUNT GROUP %+v - %v", groups, err)
+	}
+
+	sums, err := DB_SUM_GROUP(db, tbl_target, tbl_group, tbl_where, DB_OrderBy("Guild", DB_ASC))
+	if err != nil || 2 != len(sums) || "blue" != sums[0].Guild || 5 != sums[0].Point || "red" != sums[1].Guild || 40 != sums[1].Point {
+		t.Fatalf("SUM GROUP %+v - %v", sums, err)
+	}
+
+	maxes, err := DB_MAX_GROUP(db, tbl_target, tbl_group, tbl_where, DB_Having(DB_Expr("MAX(Point) > ?", 20)))
+	if err != nil || 1 != len(maxes) || "red" != maxes[0].Guild || 30 != maxes[0].Point {
+		t.Fatalf("MAX GROUP %+v - %v", maxes, err)
+	}
+
+	mins, err := DB_MIN_GROUP(db, tbl_target, tbl_group, tbl_where, DB_OrderBy("Guild", DB_ASC))
+	if err != nil || 2 != len(mins) || 5 != mins[0].Point || 10 != mins[1].Point {
+		t.Fatalf("MIN GROUP %+v - %v", mins, err)
+	}
+
+	/*
+		A GROUP variant needs a GROUP BY column.
+	*/
+	DB_InitTable(&tbl_group)
+	if _, err = DB_SUM_GROUP(db, tbl_target, tbl_group, tbl_where); err == nil {
+		t.Fatal("SUM GROUP without group column did not fail")
+	}
+}
+
+type test_Player struct {
+	PlayerID int64 `PK:"true"`
+	Name     string
+	GuildID  int64
+}
+
+func (test_Player) TableName() string { return "player" }
+
+type test_Guild struct {
+	GuildID int64 `PK:"true"`
+	Title   string
+}
+
+func (test_Guild) TableName() string { return "guild" }
+
+func TestSelectJoin(t *testing.T) {
+	db := test_OpenSQLite(t,
+		"CREATE TABLE player (PlayerID INTEGER PRIMARY KEY, Name TEXT, GuildID INTEGER)",
+		"CREATE TABLE guild (GuildID INTEGER PRIMARY KEY, Title TEXT)",
+		"INSERT INTO player VALUES (1, 'alice', 10), (2, 'bob', 20)",
+		"INSERT INTO guild VALUES (10, 'knights')")
+
+	var tbl_init, tbl_select, tbl_where DB_Tuple2[test_Player, test_Guild]
+	DB_InitTable(&tbl_init, &tbl_select, &tbl_where)
+	tbl_select.T1.Name = ""
+	tbl_select.T2.Title = ""
+
+	/*
+		bob has no guild, so the LEFT JOINed columns are NULL and left as DB_InitTable made them.
+	*/
+	rows, err := DB_SELECT_JOIN(db, tbl_select, tbl_where, []DB_Join{DB_LeftJoin("T1.GuildID", "T2.GuildID")}, DB_OrderBy("T1.PlayerID", DB_ASC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 2 != len(rows) || "alice" != rows[0].T1.Name || "knights" != rows[0].T2.Title || "bob" != rows[1].T1.Name || tbl_init.T2.Title != rows[1].T2.Title {
+		t.Fatalf("LEFT JOIN got %+v", rows)
+	}
+
+	rows, err = DB_SELECT_JOIN(db, tbl_select, tbl_where, []DB_Join{DB_InnerJoin("T1.GuildID", "T2.GuildID")})
+	if err != nil || 1 != len(rows) || "alice" != rows[0].T1.Name {
+		t.Fatalf("INNER JOIN got %+v - %v", rows, err)
+	}
+
+	/*
+		A column of both tables needs the table name.
+	*/
+	if _, err = DB_SELECT_JOIN(db, tbl_select, tbl_where, []DB_Join{DB_InnerJoin("GuildID", "T2.GuildID")}); err == nil {
+		t.Fatal("ambiguous ON column did not fail")
+	}
+
+	/*
+		Each table gets the alias of its position, the where struct works per table.
+	*/
+	fake_db, fake := test_OpenFake(t, DB_MySQL)
+	var tbl_select3, tbl_where3 DB_Tuple3[test_Player, test_Guild, test_Score]
+	DB_InitTable(&tbl_select3, &tbl_where3)
+	tbl_select3.T1.Name = ""
+	tbl_select3.T3.Point = 0
+	tbl_where3.T2.Title = "knights"
+	joins := []DB_Join{DB_InnerJoin("T1.GuildID", "T2.GuildID"), DB_LeftJoin("T1.Name", "T3.Guild")}
+	if _, err = DB_SELECT_JOIN(fake_db, tbl_select3, tbl_where3, joins, DB_OrderBy("T3.Point", DB_DESC)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"SELECT `t0`.`Name`, `t2`.`Point` FROM `player` AS `t0` INNER JOIN `guild` AS `t1` ON `t0`.`GuildID` = `t1`.`GuildID` LEFT JOIN `score` AS `t2` ON `t0`.`Name` = `t2`.`Guild` WHERE `t1`.`Title` = ? ORDER BY `t2`.`Point` DESC;"}
+	if got := fake.Statements(); false == reflect.DeepEqual(want, got) {
+		t.Fatalf("statements\n got %q\nwant %q", got, want)
+	}
+}
+
+type test_Timestamps struct {
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+type test_Stat struct {
+	HP int
+	MP int
+}
+
+type test_Hero struct {
+	HeroID int64 `PK:"true"`
+	test_Timestamps
+	Stat  test_Stat `db:"stat_,inline"`
+	Extra test_Stat
+}
+
+func (test_Hero) TableName() string { return "hero" }
+
+func TestEmbeddedColumns(t *testing.T) {
+	var names []string
+	for _, col := range db_Columns(reflect.TypeOf(test_Hero{})) {
+		names = append(names, col.Name)
+	}
+	if want := []string{"HeroID", "CreateTime", "UpdateTime", "stat_HP", "stat_MP"}; false == reflect.DeepEqual(want, names) {
+		t.Fatalf("columns got %q, want %q", names, want)
+	}
+
+	db := test_OpenSQLite(t, "CREATE TABLE hero (HeroID INTEGER PRIMARY KEY, CreateTime DATETIME, UpdateTime DATETIME, stat_HP INTEGER, stat_MP INTEGER)")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tbl_insert := test_Hero{HeroID: 1, test_Timestamps: test_Timestamps{CreateTime: now, UpdateTime: now}, Stat: test_Stat{HP: 100, MP: 50}}
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	/*
+		Scanning fills the embedded and inline fields.
+	*/
+	var tbl_select, tbl_where test_Hero
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.CreateTime = time.Now()
+	tbl_select.Stat.HP = 0
+	tbl_where.Stat.MP = 50
+	rows, err := DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || false == now.Equal(rows[0].CreateTime) || 100 != rows[0].Stat.HP {
+		t.Fatalf("got %+v", rows)
+	}
+}
+
+type test_Flag struct {
+	FlagID int64 `PK:"true"`
+	Banned bool
+}
+
+func (test_Flag) TableName() string { return "flag" }
+
+type test_FieldFlag struct {
+	FlagID int64 `PK:"true"`
+	Banned DB_Field[bool]
+}
+
+func (test_FieldFlag) TableName() string { return "flag" }
+
+func TestPlainBoolColumn(t *testing.T) {
+	db := test_OpenSQLite(t, "CREATE TABLE flag (FlagID INTEGER PRIMARY KEY, Banned BOOLEAN NOT NULL)")
+
+	/*
+		A plain bool can not be told set or not, so it is an error, not a column left out.
+	*/
+	var tbl_flag test_Flag
+	DB_InitTable(&tbl_flag)
+	tbl_flag.FlagID = 1
+	tbl_flag.Banned = true
+	if _, err := DB_INSERT(db, tbl_flag); err == nil || false == strings.Contains(err.Error(), "Plain bool column") {
+		t.Fatalf("INSERT with a plain bool column - %v", err)
+	}
+	if _, err := DB_SELECT(db, tbl_flag, tbl_flag); err == nil {
+		t.Fatal("SELECT with a plain bool column did not fail")
+	}
+
+	var tbl_insert, tbl_select, tbl_where test_FieldFlag
+	DB_InitTable(&tbl_insert, &tbl_select, &tbl_where)
+	tbl_insert.FlagID = 1
+	tbl_insert.Banned = DB_Set(false)
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl_select.Banned = DB_Set(true)
+	tbl_where.Banned = DB_Set(false)
+	rows, err := DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || false != rows[0].Banned.Get() || false == rows[0].Banned.IsSet() {
+		t.Fatalf("got %+v", rows)
+	}
+}
+
+type test_Grade int
+
+/*
+Writes a grade as its name. -1 is "not set".
+*/
+type test_GradeCodec struct{}
+
+var test_GradeNames = []string{"common", "rare", "epic"}
+
+func (test_GradeCodec) IsUse(val reflect.Value) bool { return -1 != val.Int() }
+func (test_GradeCodec) Init(val reflect.Value)       { val.SetInt(-1) }
+
+func (test_GradeCodec) Encode(val reflect.Value) (interface{}, error) {
+	return test_GradeNames[val.Int()], nil
+}
+
+func (test_GradeCodec) Decode(val reflect.Value, src interface{}) error {
+	var name string
+	switch s := src.(type) {
+	case string:
+		name = s
+	case []byte:
+		name = string(s)
+	}
+
+	for i, grade := range test_GradeNames {
+		if grade == name {
+			val.SetInt(int64(i))
+			return nil
+		}
+	}
+	return db_DecodeError(src, val)
+}
+
+type test_Item struct {
+	ItemID int64 `PK:"true"`
+	Grade  test_Grade
+}
+
+func (test_Item) TableName() string { return "item" }
+
+func TestRegisteredCodec(t *testing.T) {
+	DB_RegisterCodec[test_Grade](test_GradeCodec{})
+	t.Cleanup(func() {
+		db_codecMutex.Lock()
+		defer db_codecMutex.Unlock()
+		delete(db_codecs, reflect.TypeOf(test_Grade(0)))
+	})
+	db := test_OpenSQLite(t, "CREATE TABLE item (ItemID INTEGER PRIMARY KEY, Grade TEXT NOT NULL)")
+
+	var tbl_insert test_Item
+	DB_InitTable(&tbl_insert)
+	if -1 != tbl_insert.Grade {
+		t.Fatalf("Init - %v", tbl_insert.Grade)
+	}
+	tbl_insert.ItemID = 1
+	tbl_insert.Grade = 2
+	if _, err := DB_INSERT(db, tbl_insert); err != nil {
+		t.Fatal(err)
+	}
+
+	var grade string
+	if err := db.QueryRow("SELECT Grade FROM item").Scan(&grade); err != nil || "epic" != grade {
+		t.Fatalf("stored %q - %v", grade, err)
+	}
+
+	var tbl_select, tbl_where test_Item
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.Grade = 0
+	tbl_where.Grade = 2
+	rows, err := DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 1 != len(rows) || 2 != rows[0].Grade {
+		t.Fatalf("got %+v", rows)
+	}
+
+	if _, err = db.Exec("UPDATE item SET Grade = 'legend'"); err != nil {
+		t.Fatal(err)
+	}
+	DB_InitTable(&tbl_where)
+	tbl_where.ItemID = 1
+	if _, err = DB_SELECT(db, tbl_select, tbl_where); err == nil {
+		t.Fatal("unknown grade was decoded")
+	}
+}
+
+func TestDBJobSavepoint(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL)
+
+	rows := make([]test_Profile, 3)
+	for i := range rows {
+		DB_InitTable(&rows[i])
+		rows[i].ProfileID = int64(i + 1)
+		rows[i].Name = "p"
+	}
+
+	/*
+		The duplicate key fails inside the optional group, so only the group is rolled back.
+	*/
+	var dbjob DBJob
+	ADD_INSERT(&dbjob, rows[0])
+	ADD_TRY_SAVEPOINT(&dbjob, "bonus")
+	ADD_INSERT(&dbjob, rows[1])
+	ADD_INSERT(&dbjob, rows[0])
+	ADD_RELEASE_SAVEPOINT(&dbjob, "bonus")
+	ADD_INSERT(&dbjob, rows[2])
+	_, results, err := dbjob.RunResults(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if false == results[2].Skipped || false == results[3].Skipped || true == results[5].Skipped {
+		t.Fatalf("got %+v", results)
+	}
+
+	var ids []int64
+	got, err := db.Query("SELECT ProfileID FROM profile ORDER BY ProfileID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for got.Next() {
+		var id int64
+		got.Scan(&id)
+		ids = append(ids, id)
+	}
+	got.Close()
+	if false == reflect.DeepEqual([]int64{1, 3}, ids) {
+		t.Fatalf("rows after the optional group - %v", ids)
+	}
+
+	/*
+		A failure outside of an optional group rolls back every job.
+	*/
+	var fail DBJob
+	rows[1].ProfileID = 4
+	ADD_INSERT(&fail, rows[1])
+	ADD_INSERT(&fail, rows[2])
+	if _, err = fail.Run(db); err == nil {
+		t.Fatal("duplicate key did not fail")
+	}
+	var count int
+	if err = db.QueryRow("SELECT COUNT(*) FROM profile").Scan(&count); err != nil || 2 != count {
+		t.Fatalf("count %v - %v", count, err)
+	}
+}
+
+func TestDBJobRetry(t *testing.T) {
+	db, fake := test_OpenFake(t, DB_MySQL)
+
+	deadlocks := 2
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) {
+		if strings.HasPrefix(query, "UPDATE") && 0 < deadlocks {
+			deadlocks--
+			return 0, &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+		}
+		return 1, nil
+	}
+
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Level = 2
+	tbl_where.ProfileID = 1
+
+	var retries []int
+	var dbjob DBJob
+	dbjob.SetRetry(DB_RetryPolicy{MaxRetry: 2, BaseDelay: time.Millisecond, OnRetry: func(attempt int, delay time.Duration, err error) {
+		retries = append(retries, attempt)
+	}})
+	ADD_UPDATE(&dbjob, tbl_target, tbl_where)
+	if _, err := dbjob.Run(db); err != nil {
+		t.Fatal(err)
+	}
+
+	update := "UPDATE `profile` SET `Level`=? WHERE `ProfileID` = ?;"
+	want := []string{"BEGIN;", update, "ROLLBACK;", "BEGIN;", update, "ROLLBACK;", "BEGIN;", update, "COMMIT;"}
+	if false == reflect.DeepEqual(want, fake.Statements()) || false == reflect.DeepEqual([]int{1, 2}, retries) {
+		t.Fatalf("got %q, retries %v", fake.Statements(), retries)
+	}
+
+	/*
+		More deadlocks than MaxRetry return the error, and a deadlock is never rolled back to a savepoint.
+	*/
+	deadlocks = 3
+	var group DBJob
+	group.SetRetry(DB_RetryPolicy{MaxRetry: 1, BaseDelay: time.Millisecond})
+	ADD_TRY_SAVEPOINT(&group, "bonus")
+	ADD_UPDATE(&group, tbl_target, tbl_where)
+	ADD_RELEASE_SAVEPOINT(&group, "bonus")
+	if _, err := group.Run(db); err == nil || false == strings.Contains(err.Error(), "Deadlock") {
+		t.Fatalf("got %v", err)
+	}
+	if 1 != deadlocks {
+		t.Fatalf("attempts - %v", 3-deadlocks)
+	}
+}
+
+func TestRetryableErrors(t *testing.T) {
+	cases := []struct {
+		d   DB_Dialect
+		err error
+		ok  bool
+	}{
+		{DB_MySQL, &mysql.MySQLError{Number: 1213}, true},
+		{DB_MySQL, fmt.Errorf("job 1 - %w", &mysql.MySQLError{Number: 1205}), true},
+		{DB_MySQL, &mysql.MySQLError{Number: 1062}, false},
+		{DB_MySQL, errors.New("Error 1213 (40001): Deadlock found"), false},
+		{DB_SQLite, sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{DB_SQLite, errors.Join(errors.New("rollback"), sqlite3.Error{Code: sqlite3.ErrLocked}), true},
+		{DB_SQLite, sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+	}
+	for i, c := range cases {
+		if c.ok != db_IsRetryable(c.d, c.err) {
+			t.Errorf("case %v - %v on %v", i, c.err, c.d.Name())
+		}
+	}
+}
+
+func TestDBJobCanceled(t *testing.T) {
+	db, fake := test_OpenFake(t, DB_MySQL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) {
+		cancel()
+		return 1, nil
+	}
+
+	var tbl_target, tbl_where test_Profile
+	DB_InitTable(&tbl_target, &tbl_where)
+	tbl_target.Level = 2
+	tbl_where.ProfileID = 1
+
+	/*
+		The first job cancels ctx, so the second one is never sent and nothing is committed.
+	*/
+	var dbjob DBJob
+	ADD_UPDATE(&dbjob, tbl_target, tbl_where)
+	tbl_where.ProfileID = 2
+	ADD_UPDATE(&dbjob, tbl_target, tbl_where)
+	if _, err := dbjob.RunContext(ctx, db); false == errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+
+	update := "UPDATE `profile` SET `Level`=? WHERE `ProfileID` = ?;"
+	statements := fake.Statements()
+	if 2 > len(statements) || false == reflect.DeepEqual([]string{"BEGIN;", update}, statements[:2]) {
+		t.Fatalf("got %q", statements)
+	}
+	for _, query := range statements[2:] {
+		if query != "ROLLBACK;" {
+			t.Fatalf("got %q", statements)
+		}
+	}
+}
+
+func TestCallerTx(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL, "INSERT INTO profile (ProfileID, Name, Level) VALUES (1, 'alice', 1)")
+
+	var tbl_target, tbl_where, tbl_select test_Profile
+	DB_InitTable(&tbl_target, &tbl_where, &tbl_select)
+	tbl_target.Level = 5
+	tbl_where.ProfileID = 1
+	tbl_select.Level = 0
+
+	/*
+		DB_* and DBJob run in the transaction of the caller, and only the caller commits or rolls back.
+	*/
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := DB_WithDialect(tx, DB_SQLite)
+	if _, err = DB_UPDATE(exec, tbl_target, tbl_where); err != nil {
+		t.Fatal(err)
+	}
+
+	var dbjob DBJob
+	dbjob.SetDialect(DB_SQLite)
+	ADD_INSERT(&dbjob, test_Profile{ProfileID: 2, Name: "bob", Level: 1, Memo: DB_UNUSE_STRING, Guild: DB_UNUSE_STRING})
+	if _, err = dbjob.Run(exec); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := DB_SELECT(exec, tbl_select, tbl_where)
+	if err != nil || 1 != len(rows) || 5 != rows[0].Level {
+		t.Fatalf("in tx got %+v - %v", rows, err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("Run committed the transaction of the caller - %v", err)
+	}
+
+	var tbl_all test_Profile
+	DB_InitTable(&tbl_all)
+	count, err := DB_COUNT(db, tbl_all)
+	if err != nil || 1 != count {
+		t.Fatalf("count after rollback %v - %v", count, err)
+	}
+	rows, err = DB_SELECT(db, tbl_select, tbl_where)
+	if err != nil || 1 != len(rows) || 1 != rows[0].Level {
+		t.Fatalf("after rollback got %+v - %v", rows, err)
+	}
+
+	/*
+		The isolation level of the caller's transaction is already set.
+	*/
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	var isolated DBJob
+	isolated.SetDialect(DB_SQLite)
+	isolated.SetIsolation(sql.LevelSerializable)
+	ADD_UPDATE(&isolated, tbl_target, tbl_where)
+	if _, err = isolated.Run(DB_WithDialect(tx, DB_SQLite)); err == nil {
+		t.Fatal("SetIsolation on the transaction of the caller did not fail")
+	}
+}
+
+func TestDBJobExpect(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL, "INSERT INTO profile (ProfileID, Name, Level) VALUES (1, 'alice', 1)")
+
+	var tbl_target, tbl_where, tbl_missing, tbl_all test_Profile
+	DB_InitTable(&tbl_target, &tbl_where, &tbl_missing, &tbl_all)
+	tbl_target.Level = 5
+	tbl_where.ProfileID = 1
+	tbl_missing.ProfileID = 9
+
+	tbl_insert := test_Profile{ProfileID: 2, Name: "bob", Level: 1, Memo: DB_UNUSE_STRING, Guild: DB_UNUSE_STRING}
+
+	/*
+		An unmet expectation reports its job index and rolls back the jobs before it.
+	*/
+	var dbjob DBJob
+	dbjob.SetDialect(DB_SQLite)
+	ADD_INSERT(&dbjob, tbl_insert)
+	ADD_UPDATE(&dbjob, tbl_target, tbl_where, DB_ExpectExactly(1))
+	ADD_DECR(&dbjob, tbl_target, tbl_missing, 1, DB_ExpectNonZero())
+	_, err := dbjob.Run(db)
+
+	var expect_err *DB_ExpectError
+	if false == errors.As(err, &expect_err) || 2 != expect_err.Index || "DECR" != expect_err.Kind || 0 != expect_err.Affected {
+		t.Fatalf("got %v", err)
+	}
+	if count, err := DB_COUNT(db, tbl_all); err != nil || 1 != count {
+		t.Fatalf("INSERT was not rolled back - %v %v", count, err)
+	}
+
+	/*
+		An unmet expectation of a deferred job reports the index of its ADD_DEFER.
+	*/
+	var deferred DBJob
+	deferred.SetDialect(DB_SQLite)
+	ADD_UPDATE(&deferred, tbl_target, tbl_where)
+	ADD_DEFER(&deferred, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, next *DBJob) error {
+		ADD_INSERT(next, tbl_insert)
+		return ADD_DELETE(next, tbl_missing, DB_ExpectExactly(1))
+	})
+	_, err = deferred.Run(db)
+	if false == errors.As(err, &expect_err) || 1 != expect_err.Index || "DELETE" != expect_err.Kind {
+		t.Fatalf("deferred got %v", err)
+	}
+
+	var tbl_select test_Profile
+	DB_InitTable(&tbl_select)
+	tbl_select.Level = 0
+	rows, err := DB_SELECT(db, tbl_select, tbl_all)
+	if err != nil || 1 != len(rows) || 1 != rows[0].Level {
+		t.Fatalf("deferred jobs were not rolled back - %+v %v", rows, err)
+	}
+}
+
+func TestDBJobResults(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL, "INSERT INTO profile (ProfileID, Name, Level) VALUES (1, 'alice', 1)")
+
+	var tbl_target, tbl_where, tbl_missing, tbl_insert test_Profile
+	DB_InitTable(&tbl_target, &tbl_where, &tbl_missing, &tbl_insert)
+	tbl_target.Level = 5
+	tbl_where.ProfileID = 1
+	tbl_missing.ProfileID = 9
+	tbl_insert.Name = "bob"
+	tbl_duplicate := tbl_insert
+	tbl_duplicate.ProfileID = 1
+
+	/*
+		The failed optional group is skipped, the rest report their own counts.
+	*/
+	var dbjob DBJob
+	dbjob.SetDialect(DB_SQLite)
+	ADD_INSERT(&dbjob, tbl_insert, tbl_insert)
+	ADD_UPDATE(&dbjob, tbl_target, tbl_missing)
+	ADD_TRY_SAVEPOINT(&dbjob, "bonus")
+	ADD_UPDATE(&dbjob, tbl_target, tbl_where)
+	ADD_INSERT(&dbjob, tbl_duplicate)
+	ADD_RELEASE_SAVEPOINT(&dbjob, "bonus")
+	ADD_DELETE(&dbjob, tbl_where)
+	affected, results, err := dbjob.RunResults(db)
+	if err != nil || 3 != affected || 7 != len(results) {
+		t.Fatalf("got %v, %v results - %v", affected, len(results), err)
+	}
+
+	want := []struct {
+		affected       int64
+		last_insert_id int64
+		skipped        bool
+	}{{2, 2, false}, {0, 0, false}, {0, 0, false}, {0, 0, true}, {0, 0, true}, {0, 0, false}, {1, 0, false}}
+	for i, result := range results {
+		if i != result.Index || want[i].affected != result.Affected || want[i].last_insert_id != result.LastInsertId || want[i].skipped != result.Skipped {
+			t.Fatalf("job %v got %+v", i, result)
+		}
+	}
+
+	/*
+		PostgreSQL has no LastInsertId, the id comes from RETURNING.
+	*/
+	pg, fake := test_OpenFake(t, DB_PostgreSQL)
+	fake.query = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"ProfileID"}, [][]driver.Value{{int64(42)}}, nil
+	}
+
+	var pg_job DBJob
+	pg_job.SetDialect(DB_PostgreSQL)
+	ADD_INSERT(&pg_job, tbl_insert)
+	_, results, err = pg_job.RunResults(pg)
+	if err != nil || 1 != len(results) || 42 != results[0].LastInsertId || 1 != results[0].Affected || true == results[0].Skipped {
+		t.Fatalf("got %+v - %v", results, err)
+	}
+
+	statements := []string{"BEGIN;", `INSERT INTO "profile" ("Name") VALUES ($1) RETURNING "ProfileID";`, "COMMIT;"}
+	if got := fake.Statements(); false == reflect.DeepEqual(statements, got) {
+		t.Fatalf("statements\n got %q\nwant %q", got, statements)
+	}
+}
+
+type test_Mail struct {
+	MailID int64 `PK:"true"`
+	Title  string
+}
+
+func (test_Mail) TableName() string { return "mail" }
+
+type test_Attachment struct {
+	AttachmentID int64 `PK:"true"`
+	MailID       int64
+	ItemID       int64
+}
+
+func (test_Attachment) TableName() string { return "attachment" }
+
+func test_MailJob(title string, item_ids []int64, calls *[]int64) *DBJob {
+	var tbl_mail test_Mail
+	DB_InitTable(&tbl_mail)
+	tbl_mail.Title = title
+
+	var dbjob DBJob
+	ADD_INSERT(&dbjob, tbl_mail)
+	ADD_DEFER(&dbjob, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error {
+		*calls = append(*calls, results[0].LastInsertId)
+
+		attachments := DB_NewTable(test_Attachment{}, len(item_ids))
+		for i := range attachments {
+			attachments[i].MailID = results[0].LastInsertId
+			attachments[i].ItemID = item_ids[i]
+		}
+		return ADD_INSERT(deferred, attachments...)
+	})
+
+	return &dbjob
+}
+
+func TestDBJobDefer(t *testing.T) {
+	db := test_OpenSQLite(t,
+		"CREATE TABLE mail (MailID INTEGER PRIMARY KEY AUTOINCREMENT, Title TEXT)",
+		"CREATE TABLE attachment (AttachmentID INTEGER PRIMARY KEY AUTOINCREMENT, MailID INTEGER, ItemID INTEGER)",
+		"INSERT INTO mail (Title) VALUES ('old')")
+
+	var calls []int64
+	dbjob := test_MailJob("reward", []int64{100, 200}, &calls)
+	dbjob.SetDialect(DB_SQLite)
+	affected, results, err := dbjob.RunResults(db)
+	if err != nil || 3 != affected || 2 != len(results) || 1 != len(results[1].Deferred) || 2 != results[1].Affected {
+		t.Fatalf("got %v, %+v - %v", affected, results, err)
+	}
+
+	var tbl_select, tbl_where test_Attachment
+	DB_InitTable(&tbl_select, &tbl_where)
+	tbl_select.ItemID = 0
+	tbl_where.MailID = 2
+	rows, err := DB_SELECT(db, tbl_select, tbl_where, DB_OrderBy("ItemID", DB_ASC))
+	if err != nil || 2 != len(rows) || 100 != rows[0].ItemID || 200 != rows[1].ItemID {
+		t.Fatalf("attachments of mail 2 got %+v - %v", rows, err)
+	}
+
+	/*
+		A retry runs make_jobs again, with the id of the new attempt.
+	*/
+	fake_db, fake := test_OpenFake(t, DB_MySQL)
+	deadlocks := 1
+	var attachment_mail_ids []interface{}
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) {
+		if strings.HasPrefix(query, "INSERT INTO `attachment`") {
+			if 0 < deadlocks {
+				deadlocks--
+				return 0, &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+			}
+			attachment_mail_ids = append(attachment_mail_ids, args[0].Value)
+		}
+		return 1, nil
+	}
+
+	calls = nil
+	retry_job := test_MailJob("reward", []int64{100}, &calls)
+	retry_job.SetRetry(DB_RetryPolicy{MaxRetry: 1, BaseDelay: time.Millisecond})
+	if _, err = retry_job.Run(fake_db); err != nil {
+		t.Fatal(err)
+	}
+	if false == reflect.DeepEqual([]int64{1, 2}, calls) || false == reflect.DeepEqual([]interface{}{int64(2)}, attachment_mail_ids) {
+		t.Fatalf("calls %v, attachment mail ids %v", calls, attachment_mail_ids)
+	}
+}
+
+func TestDBJobSelect(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL, "INSERT INTO profile (ProfileID, Name, Level) VALUES (1, 'alice', 1)")
+
+	var tbl_select, tbl_where, tbl_missing, tbl_all test_Profile
+	DB_InitTable(&tbl_select, &tbl_where, &tbl_missing, &tbl_all)
+	tbl_select.Level = 0
+	tbl_where.ProfileID = 1
+	tbl_missing.ProfileID = 9
+
+	/*
+		dest is filled inside the transaction, so the deferred job sees it.
+	*/
+	var profiles []test_Profile
+	var dbjob DBJob
+	dbjob.SetDialect(DB_SQLite)
+	ADD_SELECT(&dbjob, &profiles, tbl_select, tbl_where, DB_ForUpdate(), DB_ExpectNonZero())
+	ADD_DEFER(&dbjob, func(ctx context.Context, tx DB_Executor, results []DB_JobResult, deferred *DBJob) error {
+		var tbl_target test_Profile
+		DB_InitTable(&tbl_target)
+		tbl_target.Level = profiles[0].Level + 10
+		return ADD_UPDATE(deferred, tbl_target, tbl_where)
+	})
+	_, results, err := dbjob.RunResults(db)
+	if err != nil || 1 != results[0].Rows || 1 != len(profiles) || 1 != profiles[0].Level {
+		t.Fatalf("got %+v, %+v - %v", results, profiles, err)
+	}
+	if rows, err := DB_SELECT(db, tbl_select, tbl_where); err != nil || 1 != len(rows) || 11 != rows[0].Level {
+		t.Fatalf("deferred UPDATE got %+v - %v", rows, err)
+	}
+
+	/*
+		DB_ExpectNonZero aborts when nothing was read, and rolls back the jobs before it.
+	*/
+	var tbl_insert test_Profile
+	DB_InitTable(&tbl_insert)
+	tbl_insert.Name = "bob"
+
+	var missing []test_Profile
+	var abort DBJob
+	abort.SetDialect(DB_SQLite)
+	ADD_INSERT(&abort, tbl_insert)
+	ADD_SELECT(&abort, &missing, tbl_select, tbl_missing, DB_ExpectNonZero())
+	_, err = abort.Run(db)
+
+	var expect_err *DB_ExpectError
+	if false == errors.As(err, &expect_err) || 1 != expect_err.Index || "SELECT" != expect_err.Kind {
+		t.Fatalf("got %v", err)
+	}
+	if count, err := DB_COUNT(db, tbl_all); err != nil || 1 != count {
+		t.Fatalf("INSERT was not rolled back - %v %v", count, err)
+	}
+
+	/*
+		Lock clauses of each dialect. SQLite locks the whole database, so it has none.
+	*/
+	tests := []struct {
+		d    DB_Dialect
+		wait []interface{}
+		want []string
+	}{
+		{DB_MySQL, []interface{}{DB_SkipLocked()}, []string{"SELECT `Level` FROM `profile` WHERE `ProfileID` = ? LIMIT 1 FOR UPDATE SKIP LOCKED;", "SELECT `Level` FROM `profile` WHERE `ProfileID` = ? LOCK IN SHARE MODE;"}},
+		{DB_PostgreSQL, []interface{}{DB_NoWait()}, []string{`SELECT "Level" FROM "profile" WHERE "ProfileID" = $1 LIMIT 1 FOR UPDATE NOWAIT;`, `SELECT "Level" FROM "profile" WHERE "ProfileID" = $1 FOR SHARE;`}},
+		{DB_SQLite, nil, []string{`SELECT "Level" FROM "profile" WHERE "ProfileID" = ? LIMIT 1;`, `SELECT "Level" FROM "profile" WHERE "ProfileID" = ?;`}},
+	}
+
+	for _, test := range tests {
+		fake_db, fake := test_OpenFake(t, test.d)
+
+		var dest []test_Profile
+		var lock_job DBJob
+		lock_job.SetDialect(test.d)
+		ADD_SELECT(&lock_job, &dest, tbl_select, tbl_where, append([]interface{}{DB_ForUpdate(), DB_Limit(1)}, test.wait...)...)
+		ADD_SELECT(&lock_job, &dest, tbl_select, tbl_where, DB_ForShare())
+		if _, err = lock_job.Run(fake_db); err != nil {
+			t.Fatal(err)
+		}
+
+		want := append(append([]string{"BEGIN;"}, test.want...), "COMMIT;")
+		if got := fake.Statements(); false == reflect.DeepEqual(want, got) {
+			t.Fatalf("%v statements\n got %q\nwant %q", test.d, got, want)
+		}
+	}
+}
+
+func test_CoalesceJob(names ...string) *DBJob {
+	dbjob := &DBJob{}
+	dbjob.SetCoalesce(DB_CoalescePolicy{MaxPlaceholders: 4})
+	for _, name := range names {
+		var tbl_insert test_Profile
+		DB_InitTable(&tbl_insert)
+		tbl_insert.Name = name
+		tbl_insert.Guild = "red"
+		ADD_INSERT(dbjob, tbl_insert)
+	}
+	return dbjob
+}
+
+func TestDBJobCoalesce(t *testing.T) {
+	db := test_OpenSQLite(t, test_ProfileDDL)
+
+	/*
+		Two placeholders a row and four a statement, so three jobs are two statements.
+	*/
+	_, results, err := test_CoalesceJob("a", "b", "c").RunResults(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	two := `INSERT INTO "profile" ("Name", "Guild") VALUES (?, ?), (?, ?);`
+	one := `INSERT INTO "profile" ("Name", "Guild") VALUES (?, ?);`
+	for i, want := range []struct {
+		sql string
+		id  int64
+	}{{two, 1}, {two, 2}, {one, 3}} {
+		if want.sql != results[i].SQL || 1 != results[i].Affected || want.id != results[i].LastInsertId {
+			t.Fatalf("job %v - %+v", i, results[i])
+		}
+	}
+}
+
+func TestDBJobCoalesceAffected(t *testing.T) {
+	db, fake := test_OpenFake(t, DB_MySQL)
+	fake.exec = func(query string, args []driver.NamedValue) (int64, error) { return 1, nil }
+
+	/*
+		A statement of two rows which affected one row fails Run, instead of giving each job one row.
+	*/
+	if _, err := test_CoalesceJob("a", "b").Run(db); err == nil || false == strings.Contains(err.Error(), "affected 1 rows, but has 2 rows") {
+		t.Fatalf("got %v", err)
+	}
+	statements := fake.Statements()
+	if "ROLLBACK;" != statements[len(statements)-1] {
+		t.Fatalf("got %q", statements)
+	}
+	ids, affected, err := db_ExecInsertIDs(context.Background(), db, DB_MySQL, "INSERT", nil, "ProfileID", 2)
+	if err != nil || 1 != affected || 0 != len(ids) {
+		t.Fatalf("ids %v of %v rows - %v", ids, affected, err)
+	}
+}